@@ -16,53 +16,65 @@ limitations under the License.
 package base
 
 import (
+	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/pterm/pterm"
-	"github.com/tabarnhack/git-switch/config"
-	"github.com/tabarnhack/git-switch/io/prompt"
 	bolt "go.etcd.io/bbolt"
 )
 
-var bucketName = []byte("users")
+var (
+	bucketName  = []byte("users")
+	metaBucket  = []byte("meta")
+	revisionKey = []byte("revision")
+	saltKey     = []byte("salt")
+	verifierKey = []byte("verifier")
+	ErrConflict = errors.New("profiles modified concurrently by another process")
+)
+
+// verifier is sealed in encrypted databases to check the passphrase
+const verifier = "git-switch"
 
+// Unlocker returns the passphrase of an encrypted database
+type Unlocker func() ([]byte, error)
+
+// Base stores the git profiles inside a bbolt database
 type Base struct {
+	*Memory
+
 	filename string
+	readOnly bool
+	cipher   *Cipher
 
-	entries map[string]string
+	// revision and loaded are the state of the database when it was last
+	// read, they are used to detect concurrent modifications on save
+	revision uint64
+	loaded   map[string]string
 }
 
-func New(conf config.DatabaseConfig) (*Base, error) {
-	var path string
-	var err error
+func New(path string, readOnly bool, unlock Unlocker) (*Base, error) {
+	b := &Base{Memory: NewMemory(), filename: path, readOnly: readOnly}
 
-	if conf.Path != "" {
-		path = conf.Path
-	} else {
-		var found bool
-		path, found = searchDB(conf)
-		if !found {
-			path, err = createDB(conf)
+	var salt, sealed []byte
+	err := b.update(func(tx *bolt.Tx) error {
+		if !readOnly {
+			_, err := tx.CreateBucketIfNotExists(bucketName)
 			if err != nil {
-				return nil, err
+				return fmt.Errorf("create bucket: %s", err)
+			}
+			_, err = tx.CreateBucketIfNotExists(metaBucket)
+			if err != nil {
+				return fmt.Errorf("create bucket: %s", err)
 			}
 		}
-	}
-
-	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
-	if err != nil {
-		return nil, err
-	}
-	defer db.Close()
 
-	err = db.Update(func(tx *bolt.Tx) error {
-		_, err := tx.CreateBucketIfNotExists(bucketName)
-		if err != nil {
-			return fmt.Errorf("create bucket: %s", err)
+		if meta := tx.Bucket(metaBucket); meta != nil {
+			salt = copyBytes(meta.Get(saltKey))
+			sealed = copyBytes(meta.Get(verifierKey))
 		}
 		return nil
 	})
@@ -70,146 +82,329 @@ func New(conf config.DatabaseConfig) (*Base, error) {
 		return nil, err
 	}
 
-	entries := make(map[string]string)
-	err = db.View(func(tx *bolt.Tx) error {
-		tx.Bucket(bucketName).ForEach(func(k, v []byte) error {
-			entries[string(k)] = string(v)
-			return nil
-		})
-		return nil
-	})
+	// The database is closed while waiting for the passphrase so other
+	// processes aren't locked out
+	if salt != nil {
+		if unlock == nil {
+			return nil, errors.New("the database is encrypted and no passphrase has been provided")
+		}
 
-	return &Base{filename: path, entries: entries}, err
-}
+		passphrase, err := unlock()
+		if err != nil {
+			return nil, err
+		}
+
+		b.cipher, err = NewCipher(passphrase, salt)
+		if err != nil {
+			return nil, err
+		}
 
-func searchDB(conf config.DatabaseConfig) (string, bool) {
-	for _, v := range conf.SearchPaths {
-		path := filepath.Join(v, conf.Filename)
-		db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
-		if err == nil {
-			db.Close()
-			return path, true
+		if _, err := b.cipher.Open(string(verifierKey), sealed); err != nil {
+			return nil, err
 		}
 	}
 
-	return "", false
+	err = b.view(b.load)
+	return b, err
 }
 
-func createDB(conf config.DatabaseConfig) (string, error) {
-	var path string
-	var created bool
+// Encrypted reports whether the values of the database are sealed
+func (b *Base) Encrypted() bool {
+	return b.cipher != nil
+}
 
-	create, err := prompt.Confirm("No database has been found. Do you want to create one")
+// Encrypt seals every profile with a key derived from the passphrase. It is
+// also used to change the passphrase of an encrypted database. Only the
+// emails are sealed: the names, which are the keys of the database, stay in
+// plain text.
+func (b *Base) Encrypt(passphrase []byte) error {
+	c, err := NewCipher(passphrase, nil)
 	if err != nil {
-		return "", err
+		return err
 	}
 
-	if !create {
-		return "", fmt.Errorf("Cannot find user database inside the search paths %v", conf.SearchPaths)
+	return b.rewrite(c)
+}
+
+// Decrypt stores every profile in plain text
+func (b *Base) Decrypt() error {
+	if b.cipher == nil {
+		return errors.New("the database is not encrypted")
 	}
 
-	for _, v := range conf.SearchPaths {
-		if err := os.MkdirAll(v, 0700); err == nil {
-			created = true
-			path = filepath.Join(v, conf.Filename)
-			break
-		}
+	return b.rewrite(nil)
+}
+
+// Save writes the profiles changed since the database was read. If another
+// process saved in the meantime, its changes are kept as long as they do not
+// touch the same profiles, otherwise ErrConflict is returned. The profiles
+// are then reloaded with the local changes applied over them, so saving
+// again overwrites the conflicting profiles.
+func (b *Base) Save() error {
+	if b.readOnly {
+		return ErrReadOnly
 	}
 
-	if !created {
-		return "", fmt.Errorf("Cannot create user database in any of the search paths %v", conf.SearchPaths)
+	changes := b.changes()
+	if len(changes) == 0 {
+		return nil
 	}
 
-	pterm.Info.Println("User database created at:", path)
-	return path, nil
-}
+	return b.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
 
-func (b *Base) List() []Entry {
-	entries := make([]Entry, 0)
-	for name, email := range b.entries {
-		entries = append(entries, Entry{Name: name, Email: email})
-	}
+		// Writing with the cipher the database was read with would mix sealed
+		// and plain values once another process has changed the encryption
+		var cipherSalt []byte
+		if b.cipher != nil {
+			cipherSalt = b.cipher.salt
+		}
+		if !bytes.Equal(meta.Get(saltKey), cipherSalt) {
+			return fmt.Errorf("%w: the database has been encrypted, decrypted or rekeyed since it was read", ErrConflict)
+		}
+
+		revision := readRevision(meta)
+		if revision != b.revision {
+			var conflicts []string
+			for name, value := range changes {
+				stored, err := b.decode(name, bucket.Get([]byte(name)))
+				if err != nil {
+					return err
+				}
+
+				prev, existed := b.loaded[name]
+				if (stored == nil) == !existed && string(stored) == prev {
+					continue
+				}
+
+				// The other process made the same change, nothing to write
+				if (stored == nil) == (value == nil) && string(stored) == string(value) {
+					delete(changes, name)
+					continue
+				}
+
+				conflicts = append(conflicts, name)
+			}
+
+			if len(conflicts) > 0 {
+				sort.Strings(conflicts)
+				if err := b.rebase(tx, changes); err != nil {
+					return err
+				}
+				return fmt.Errorf("%w: %s", ErrConflict, strings.Join(conflicts, ", "))
+			}
+		}
 
-	return entries
+		for name, value := range changes {
+			if value == nil {
+				err = bucket.Delete([]byte(name))
+			} else {
+				err = b.put(bucket, b.cipher, name, value)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := writeRevision(meta, revision+1); err != nil {
+			return err
+		}
+
+		// Reload to pick up the changes merged from other processes
+		return b.load(tx)
+	})
 }
 
-func (b *Base) Get(name string) (Entry, error) {
-	if _, ok := b.entries[name]; !ok {
-		return Entry{}, fmt.Errorf("no entry with the name %s exists", name)
+// rebase reloads the profiles stored by the other processes and applies the
+// changes over them
+func (b *Base) rebase(tx *bolt.Tx, changes map[string][]byte) error {
+	if err := b.load(tx); err != nil {
+		return err
 	}
 
-	return Entry{Name: name, Email: b.entries[name]}, nil
+	for name, value := range changes {
+		if value == nil {
+			delete(b.entries, name)
+		} else {
+			b.entries[name] = string(value)
+		}
+	}
+
+	return nil
 }
 
-func (b *Base) Add(user Entry) error {
-	if user.IsIncomplete() {
-		return errors.New("cannot add incomplete user to the database")
+// rewrite stores every profile again, sealed with the given cipher or in
+// plain text if it is nil.
+func (b *Base) rewrite(c *Cipher) error {
+	if b.readOnly {
+		return ErrReadOnly
 	}
 
-	if _, ok := b.entries[user.Name]; ok {
-		return fmt.Errorf("an entry with the name %s already exists", user.Name)
+	if len(b.changes()) != 0 {
+		return errors.New("the database has unsaved changes")
 	}
 
-	b.entries[user.Name] = user.Email
+	return b.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		meta := tx.Bucket(metaBucket)
 
-	return nil
+		revision := readRevision(meta)
+		if revision != b.revision {
+			return ErrConflict
+		}
+
+		for name, email := range b.loaded {
+			if err := b.put(bucket, c, name, []byte(email)); err != nil {
+				return err
+			}
+		}
+
+		if c == nil {
+			if err := meta.Delete(saltKey); err != nil {
+				return err
+			}
+			if err := meta.Delete(verifierKey); err != nil {
+				return err
+			}
+		} else {
+			sealed, err := c.Seal(string(verifierKey), []byte(verifier))
+			if err != nil {
+				return err
+			}
+			if err := meta.Put(saltKey, c.salt); err != nil {
+				return err
+			}
+			if err := meta.Put(verifierKey, sealed); err != nil {
+				return err
+			}
+		}
+
+		if err := writeRevision(meta, revision+1); err != nil {
+			return err
+		}
+
+		b.cipher = c
+		b.revision = revision + 1
+		return nil
+	})
 }
 
-func (b *Base) Update(prev, curr Entry) error {
-	if _, ok := b.entries[prev.Name]; !ok {
-		return fmt.Errorf("no entry with the name %s exists", prev.Name)
+// changes returns the profiles modified since the database was read, a nil
+// value meaning the profile has been deleted.
+func (b *Base) changes() map[string][]byte {
+	changes := make(map[string][]byte)
+	for name, email := range b.entries {
+		if prev, ok := b.loaded[name]; !ok || prev != email {
+			changes[name] = []byte(email)
+		}
+	}
+
+	for name := range b.loaded {
+		if _, ok := b.entries[name]; !ok {
+			changes[name] = nil
+		}
 	}
 
-	if curr.Name != prev.Name {
-		if _, ok := b.entries[curr.Name]; ok {
-			return fmt.Errorf("cannot update name to %s as it already exists", curr.Name)
+	return changes
+}
+
+func (b *Base) load(tx *bolt.Tx) error {
+	b.entries = make(map[string]string)
+	b.loaded = make(map[string]string)
+
+	// The bucket is missing from a database never opened in write mode
+	if bucket := tx.Bucket(bucketName); bucket != nil {
+		err := bucket.ForEach(func(k, v []byte) error {
+			value, err := b.decode(string(k), v)
+			if err != nil {
+				return err
+			}
+
+			b.entries[string(k)] = string(value)
+			b.loaded[string(k)] = string(value)
+			return nil
+		})
+		if err != nil {
+			return err
 		}
-		delete(b.entries, prev.Name)
 	}
 
-	b.entries[curr.Name] = curr.Email
+	if meta := tx.Bucket(metaBucket); meta != nil {
+		b.revision = readRevision(meta)
+	}
 
 	return nil
 }
 
-func (b *Base) Delete(name string) error {
-	if _, ok := b.entries[name]; !ok {
-		return fmt.Errorf("no entry with the name %s exists", name)
+func (b *Base) put(bucket *bolt.Bucket, c *Cipher, name string, value []byte) error {
+	if c != nil {
+		var err error
+		value, err = c.Seal(name, value)
+		if err != nil {
+			return err
+		}
 	}
 
-	delete(b.entries, name)
+	return bucket.Put([]byte(name), value)
+}
+
+func (b *Base) decode(name string, value []byte) ([]byte, error) {
+	if value == nil || b.cipher == nil {
+		return value, nil
+	}
 
-	return nil
+	return b.cipher.Open(name, value)
 }
 
-func (b *Base) Print() {
-	for _, entry := range b.List() {
-		fmt.Printf("name=%s, email=%s\n", entry.Name, entry.Email)
+func (b *Base) view(fn func(*bolt.Tx) error) error {
+	db, err := bolt.Open(b.filename, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: b.readOnly})
+	if err != nil {
+		return err
 	}
+	defer db.Close()
+
+	return db.View(fn)
 }
 
-func (b *Base) Save() error {
+// update runs fn in a read-write transaction, or in a read-only one if the
+// database has been opened in read-only mode
+func (b *Base) update(fn func(*bolt.Tx) error) error {
+	if b.readOnly {
+		return b.view(fn)
+	}
+
 	db, err := bolt.Open(b.filename, 0600, &bolt.Options{Timeout: 1 * time.Second})
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	return db.Update(func(tx *bolt.Tx) error {
-		// We empty the current base, we assume no modification has been made since `New` call
-		err := tx.DeleteBucket(bucketName)
-		if err != nil {
-			return err
-		}
-		bucket, err := tx.CreateBucket(bucketName)
+	return db.Update(fn)
+}
 
-		for name, email := range b.entries {
-			err = bucket.Put([]byte(name), []byte(email))
-			if err != nil {
-				break
-			}
-		}
+func readRevision(meta *bolt.Bucket) uint64 {
+	v := meta.Get(revisionKey)
+	if len(v) != 8 {
+		return 0
+	}
 
-		return err
-	})
+	return binary.BigEndian.Uint64(v)
+}
+
+func writeRevision(meta *bolt.Bucket, revision uint64) error {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, revision)
+	return meta.Put(revisionKey, buf)
+}
+
+func copyBytes(v []byte) []byte {
+	if v == nil {
+		return nil
+	}
+
+	return append([]byte{}, v...)
 }
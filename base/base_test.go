@@ -0,0 +1,269 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// openPair opens the database twice, as two processes would, once it holds
+// the entries
+func openPair(t *testing.T, entries ...Entry) (*Base, *Base, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "profiles.db")
+	b, err := New(path, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if err := b.Add(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := New(path, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := New(path, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return first, second, path
+}
+
+// stored returns the profiles saved in the database, sorted by name
+func stored(t *testing.T, path string) []Entry {
+	t.Helper()
+
+	b, err := New(path, true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries := b.List()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+func TestSaveConcurrent(t *testing.T) {
+	alice := Entry{Name: "alice", Email: "alice@example.com"}
+	bob := Entry{Name: "bob", Email: "bob@example.com"}
+	carol := Entry{Name: "carol", Email: "carol@example.com"}
+	dave := Entry{Name: "dave", Email: "dave@example.com"}
+
+	tests := []struct {
+		name     string
+		first    func(b *Base) error
+		second   func(b *Base) error
+		conflict bool
+		// want is stored once the second process has saved, again after a
+		// conflict
+		want []Entry
+	}{
+		{
+			name:   "separate adds",
+			first:  func(b *Base) error { return b.Add(carol) },
+			second: func(b *Base) error { return b.Add(dave) },
+			want:   []Entry{alice, bob, carol, dave},
+		},
+		{
+			name:   "separate edit and delete",
+			first:  func(b *Base) error { return b.Update(alice, Entry{Name: "alice", Email: "new@example.com"}) },
+			second: func(b *Base) error { return b.Delete("bob") },
+			want:   []Entry{{Name: "alice", Email: "new@example.com"}},
+		},
+		{
+			name:   "same edit",
+			first:  func(b *Base) error { return b.Update(alice, Entry{Name: "alice", Email: "new@example.com"}) },
+			second: func(b *Base) error { return b.Update(alice, Entry{Name: "alice", Email: "new@example.com"}) },
+			want:   []Entry{{Name: "alice", Email: "new@example.com"}, bob},
+		},
+		{
+			name:     "edit and edit",
+			first:    func(b *Base) error { return b.Update(alice, Entry{Name: "alice", Email: "first@example.com"}) },
+			second:   func(b *Base) error { return b.Update(alice, Entry{Name: "alice", Email: "second@example.com"}) },
+			conflict: true,
+			want:     []Entry{{Name: "alice", Email: "second@example.com"}, bob},
+		},
+		{
+			name:     "delete and edit",
+			first:    func(b *Base) error { return b.Delete("alice") },
+			second:   func(b *Base) error { return b.Update(alice, Entry{Name: "alice", Email: "second@example.com"}) },
+			conflict: true,
+			want:     []Entry{{Name: "alice", Email: "second@example.com"}, bob},
+		},
+		{
+			name:     "add and add",
+			first:    func(b *Base) error { return b.Add(Entry{Name: "carol", Email: "first@example.com"}) },
+			second:   func(b *Base) error { return b.Add(carol) },
+			conflict: true,
+			want:     []Entry{alice, bob, carol},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			first, second, path := openPair(t, alice, bob)
+
+			if err := test.first(first); err != nil {
+				t.Fatal(err)
+			}
+			if err := first.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := test.second(second); err != nil {
+				t.Fatal(err)
+			}
+			err := second.Save()
+			if test.conflict {
+				if !errors.Is(err, ErrConflict) {
+					t.Fatalf("Save() error = %v, want %v", err, ErrConflict)
+				}
+
+				// The conflicting change is kept and written on the next save
+				err = second.Save()
+			}
+			if err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+
+			if got := stored(t, path); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("stored profiles = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSaveAfterConflictKeepsOtherChanges(t *testing.T) {
+	alice := Entry{Name: "alice", Email: "alice@example.com"}
+	bob := Entry{Name: "bob", Email: "bob@example.com"}
+	first, second, path := openPair(t, alice, bob)
+
+	if err := first.Update(alice, Entry{Name: "alice", Email: "first@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Add(Entry{Name: "carol", Email: "carol@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := second.Update(alice, Entry{Name: "alice", Email: "second@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Save() error = %v, want %v", err, ErrConflict)
+	}
+
+	// The profiles of the other process are loaded along with the conflict
+	if _, err := second.Get("carol"); err != nil {
+		t.Errorf("Get() after the conflict: %v", err)
+	}
+
+	if err := second.Delete("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	want := []Entry{{Name: "alice", Email: "second@example.com"}, {Name: "carol", Email: "carol@example.com"}}
+	if got := stored(t, path); !reflect.DeepEqual(got, want) {
+		t.Errorf("stored profiles = %v, want %v", got, want)
+	}
+}
+
+func TestSaveAfterEncryptionChanged(t *testing.T) {
+	passphrase := func() ([]byte, error) { return []byte("secret"), nil }
+
+	tests := []struct {
+		name    string
+		before  func(b *Base) error
+		change  func(b *Base) error
+		unlock  Unlocker
+		prepare Unlocker
+	}{
+		{
+			name:   "encrypted",
+			change: func(b *Base) error { return b.Encrypt([]byte("secret")) },
+		},
+		{
+			name:    "decrypted",
+			before:  func(b *Base) error { return b.Encrypt([]byte("secret")) },
+			change:  func(b *Base) error { return b.Decrypt() },
+			unlock:  passphrase,
+			prepare: passphrase,
+		},
+		{
+			name:    "rekeyed",
+			before:  func(b *Base) error { return b.Encrypt([]byte("secret")) },
+			change:  func(b *Base) error { return b.Encrypt([]byte("secret")) },
+			unlock:  passphrase,
+			prepare: passphrase,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "users.db")
+			first, err := New(path, false, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.before != nil {
+				if err := test.before(first); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			stale, err := New(path, false, test.unlock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			other, err := New(path, false, test.prepare)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := test.change(other); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := stale.Add(Entry{Name: "alice", Email: "alice@example.com"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := stale.Save(); !errors.Is(err, ErrConflict) {
+				t.Fatalf("Save() error = %v, want %v", err, ErrConflict)
+			}
+
+			// The database is still readable with its current encryption
+			if _, err := New(path, true, passphrase); err != nil {
+				t.Errorf("New() after the refused save: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	saltSize = 16
+	keySize  = 32
+
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var ErrWrongPassphrase = errors.New("wrong passphrase for the encrypted database")
+
+// Cipher seals the values of an encrypted database with AES-GCM using a key
+// derived from a passphrase.
+type Cipher struct {
+	salt []byte
+	aead cipher.AEAD
+}
+
+// NewCipher derives the key from the passphrase. A random salt is generated
+// when none is given.
+func NewCipher(passphrase, salt []byte) (*Cipher, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("empty passphrase")
+	}
+
+	if salt == nil {
+		salt = make([]byte, saltSize)
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Cipher{salt: salt, aead: aead}, nil
+}
+
+// Seal encrypts the value stored under name. The name is authenticated so a
+// sealed value can't be moved to another profile.
+func (c *Cipher) Seal(name string, value []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return c.aead.Seal(nonce, nonce, value, []byte(name)), nil
+}
+
+func (c *Cipher) Open(name string, sealed []byte) ([]byte, error) {
+	if len(sealed) < c.aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	value, err := c.aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	return value, nil
+}
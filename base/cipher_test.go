@@ -0,0 +1,85 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCipher(t *testing.T) {
+	salt := bytes.Repeat([]byte{1}, saltSize)
+	seal, err := NewCipher([]byte("secret"), salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := seal.Seal("alice", []byte("alice@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		passphrase string
+		salt       []byte
+		profile    string
+		sealed     []byte
+		err        error
+	}{
+		{"same passphrase and salt", "secret", salt, "alice", sealed, nil},
+		{"wrong passphrase", "other", salt, "alice", sealed, ErrWrongPassphrase},
+		{"other salt", "secret", bytes.Repeat([]byte{2}, saltSize), "alice", sealed, ErrWrongPassphrase},
+		{"moved to another profile", "secret", salt, "bob", sealed, ErrWrongPassphrase},
+		{"truncated", "secret", salt, "alice", sealed[:4], ErrWrongPassphrase},
+		{"tampered", "secret", salt, "alice", append(append([]byte{}, sealed[:len(sealed)-1]...), sealed[len(sealed)-1]^1), ErrWrongPassphrase},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := NewCipher([]byte(test.passphrase), test.salt)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			value, err := c.Open(test.profile, test.sealed)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Open() error = %v, want %v", err, test.err)
+			}
+			if err == nil && string(value) != "alice@example.com" {
+				t.Errorf("Open() = %q, want %q", value, "alice@example.com")
+			}
+		})
+	}
+}
+
+func TestNewCipher(t *testing.T) {
+	if _, err := NewCipher(nil, nil); err == nil {
+		t.Error("NewCipher() with an empty passphrase succeeded")
+	}
+
+	a, err := NewCipher([]byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewCipher([]byte("secret"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.salt) != saltSize || bytes.Equal(a.salt, b.salt) {
+		t.Errorf("NewCipher() salts %x and %x, want two random salts of %d bytes", a.salt, b.salt, saltSize)
+	}
+}
@@ -15,6 +15,12 @@ limitations under the License.
 */
 package base
 
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
 type Entry struct {
 	Name  string
 	Email string
@@ -31,3 +37,29 @@ func (e Entry) IsEmpty() bool {
 func (e Entry) IsIncomplete() bool {
 	return e.Name == "" || e.Email == ""
 }
+
+// Validate checks the entry with the same rules used when creating a user
+func (e Entry) Validate() error {
+	if err := ValidateName(e.Name); err != nil {
+		return err
+	}
+
+	return ValidateEmail(e.Email)
+}
+
+func ValidateName(name string) error {
+	if len(strings.TrimSpace(name)) == 0 {
+		return errors.New("Empty name")
+	}
+
+	return nil
+}
+
+func ValidateEmail(email string) error {
+	if len(strings.TrimSpace(email)) == 0 {
+		return errors.New("Empty email")
+	}
+
+	_, err := mail.ParseAddress(email)
+	return err
+}
@@ -0,0 +1,113 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+// File stores the git profiles inside a human-editable YAML or TOML file
+type File struct {
+	*Memory
+
+	filename string
+	driver   string
+	readOnly bool
+}
+
+type fileContent struct {
+	Profiles []fileEntry `yaml:"profiles" toml:"profiles"`
+}
+
+type fileEntry struct {
+	Name  string `yaml:"name" toml:"name"`
+	Email string `yaml:"email" toml:"email"`
+}
+
+func NewFile(path, driver string, readOnly bool) (*File, error) {
+	f := &File{Memory: NewMemory(), filename: path, driver: driver, readOnly: readOnly}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !readOnly {
+		return f, f.Save()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var content fileContent
+	if err := f.unmarshal(data, &content); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %s", path, err)
+	}
+
+	for _, entry := range content.Profiles {
+		if err := f.Add(Entry{Name: entry.Name, Email: entry.Email}); err != nil {
+			return nil, fmt.Errorf("invalid profile in %s: %s", path, err)
+		}
+	}
+
+	return f, nil
+}
+
+func (f *File) Save() error {
+	if f.readOnly {
+		return ErrReadOnly
+	}
+
+	content := fileContent{Profiles: make([]fileEntry, 0)}
+	for _, entry := range f.List() {
+		content.Profiles = append(content.Profiles, fileEntry{Name: entry.Name, Email: entry.Email})
+	}
+
+	// Keep a stable order so the file can be tracked in dotfiles
+	sort.Slice(content.Profiles, func(i, j int) bool {
+		return content.Profiles[i].Name < content.Profiles[j].Name
+	})
+
+	data, err := f.marshal(content)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(f.filename, data, 0600)
+}
+
+func (f *File) marshal(content fileContent) ([]byte, error) {
+	if f.driver == TOMLDriver {
+		return toml.Marshal(content)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(content)
+	return buf.Bytes(), err
+}
+
+func (f *File) unmarshal(data []byte, content *fileContent) error {
+	if f.driver == TOMLDriver {
+		return toml.Unmarshal(data, content)
+	}
+
+	return yaml.Unmarshal(data, content)
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"fmt"
+)
+
+// Layer is one of the databases merged by Layered
+type Layer struct {
+	Path  string
+	Store ProfileStore
+}
+
+// Layered merges several databases by precedence: a profile of a layer hides
+// the profiles with the same name in the following layers. Only the first
+// layer is written to, the others are read-only catalogs.
+type Layered struct {
+	layers []Layer
+}
+
+func NewLayered(layers ...Layer) *Layered {
+	return &Layered{layers: layers}
+}
+
+// Writable returns the store every change is written to
+func (l *Layered) Writable() ProfileStore {
+	return l.layers[0].Store
+}
+
+// Origin returns the path of the database the profile comes from
+func (l *Layered) Origin(name string) string {
+	for _, layer := range l.layers {
+		if _, err := layer.Store.Get(name); err == nil {
+			return layer.Path
+		}
+	}
+
+	return ""
+}
+
+func (l *Layered) List() []Entry {
+	seen := make(map[string]bool)
+	entries := make([]Entry, 0)
+	for _, layer := range l.layers {
+		for _, entry := range layer.Store.List() {
+			if !seen[entry.Name] {
+				seen[entry.Name] = true
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	return entries
+}
+
+func (l *Layered) Get(name string) (Entry, error) {
+	for _, layer := range l.layers {
+		if entry, err := layer.Store.Get(name); err == nil {
+			return entry, nil
+		}
+	}
+
+	return Entry{}, notFound(fmt.Sprintf("no entry with the name %s exists", name))
+}
+
+func (l *Layered) Add(user Entry) error {
+	if _, err := l.Get(user.Name); err == nil {
+		return fmt.Errorf("an entry with the name %s already exists", user.Name)
+	}
+
+	return l.Writable().Add(user)
+}
+
+// Update edits the profile in the writable layer. A profile of a read-only
+// layer is overridden by a copy in the writable layer.
+func (l *Layered) Update(prev, curr Entry) error {
+	if _, err := l.Writable().Get(prev.Name); err == nil {
+		if curr.Name != prev.Name {
+			if _, err := l.Get(curr.Name); err == nil {
+				return fmt.Errorf("cannot update name to %s as it already exists", curr.Name)
+			}
+		}
+		return l.Writable().Update(prev, curr)
+	}
+
+	if _, err := l.Get(prev.Name); err != nil {
+		return err
+	}
+
+	if curr.Name != prev.Name {
+		return fmt.Errorf("cannot rename %s as it belongs to the read-only database %s", prev.Name, l.Origin(prev.Name))
+	}
+
+	return l.Writable().Add(curr)
+}
+
+// Delete removes the profile from the writable layer, uncovering the profile
+// with the same name of a read-only layer if any
+func (l *Layered) Delete(name string) error {
+	if _, err := l.Writable().Get(name); err == nil {
+		return l.Writable().Delete(name)
+	}
+
+	if origin := l.Origin(name); origin != "" {
+		return fmt.Errorf("cannot delete %s as it belongs to the read-only database %s", name, origin)
+	}
+
+	return notFound(fmt.Sprintf("no entry with the name %s exists", name))
+}
+
+func (l *Layered) Save() error {
+	return l.Writable().Save()
+}
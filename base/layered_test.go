@@ -0,0 +1,123 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func sortedList(store ProfileStore) []Entry {
+	entries := store.List()
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	return entries
+}
+
+func TestLayeredPrecedence(t *testing.T) {
+	alice := Entry{Name: "alice", Email: "alice@home.org"}
+	personal := Entry{Name: "shared", Email: "alice@shared.org"}
+	catalog := Entry{Name: "shared", Email: "team@shared.org"}
+	team := Entry{Name: "team", Email: "team@corp.com"}
+
+	l := NewLayered(
+		Layer{Path: "personal.db", Store: NewMemory(alice, personal)},
+		Layer{Path: "catalog.db", Store: NewMemory(catalog, team)},
+	)
+
+	if got, want := sortedList(l), []Entry{alice, personal, team}; !reflect.DeepEqual(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+
+	// The personal profile shadows the catalog one
+	if got, err := l.Get("shared"); err != nil || got != personal {
+		t.Errorf("Get(shared) = %v, %v, want %v", got, err, personal)
+	}
+
+	origins := map[string]string{"alice": "personal.db", "shared": "personal.db", "team": "catalog.db", "nobody": ""}
+	for name, want := range origins {
+		if got := l.Origin(name); got != want {
+			t.Errorf("Origin(%s) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestLayered(t *testing.T) {
+	alice := Entry{Name: "alice", Email: "alice@home.org"}
+	personal := Entry{Name: "shared", Email: "alice@shared.org"}
+	catalog := Entry{Name: "shared", Email: "team@shared.org"}
+	team := Entry{Name: "team", Email: "team@corp.com"}
+	newTeam := Entry{Name: "team", Email: "alice@corp.com"}
+	bob := Entry{Name: "bob", Email: "bob@home.org"}
+
+	tests := []struct {
+		name     string
+		op       func(l *Layered) error
+		writable []Entry
+		list     []Entry
+		wantErr  bool
+		err      error
+	}{
+		{"add", func(l *Layered) error { return l.Add(bob) },
+			[]Entry{alice, bob, personal}, []Entry{alice, bob, personal, team}, false, nil},
+		{"add catalog name", func(l *Layered) error { return l.Add(Entry{Name: "team", Email: "bob@corp.com"}) },
+			[]Entry{alice, personal}, []Entry{alice, personal, team}, true, nil},
+		{"update personal", func(l *Layered) error { return l.Update(alice, Entry{Name: "alice", Email: "alice@new.org"}) },
+			[]Entry{{Name: "alice", Email: "alice@new.org"}, personal}, []Entry{{Name: "alice", Email: "alice@new.org"}, personal, team}, false, nil},
+		// The catalog profile is copied to the writable layer, which shadows it
+		{"update catalog", func(l *Layered) error { return l.Update(team, newTeam) },
+			[]Entry{alice, personal, newTeam}, []Entry{alice, personal, newTeam}, false, nil},
+		{"rename catalog", func(l *Layered) error { return l.Update(team, Entry{Name: "corp", Email: team.Email}) },
+			[]Entry{alice, personal}, []Entry{alice, personal, team}, true, nil},
+		{"rename to a catalog name", func(l *Layered) error { return l.Update(alice, Entry{Name: "team", Email: alice.Email}) },
+			[]Entry{alice, personal}, []Entry{alice, personal, team}, true, nil},
+		// Deleting the personal profile uncovers the catalog one
+		{"delete shadowing", func(l *Layered) error { return l.Delete("shared") },
+			[]Entry{alice}, []Entry{alice, catalog, team}, false, nil},
+		{"delete catalog", func(l *Layered) error { return l.Delete("team") },
+			[]Entry{alice, personal}, []Entry{alice, personal, team}, true, nil},
+		{"delete missing", func(l *Layered) error { return l.Delete("bob") },
+			[]Entry{alice, personal}, []Entry{alice, personal, team}, true, ErrNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			writable := NewMemory(alice, personal)
+			catalogStore := NewMemory(catalog, team)
+			l := NewLayered(Layer{Path: "personal.db", Store: writable}, Layer{Path: "catalog.db", Store: catalogStore})
+
+			err := test.op(l)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error = %v, want error %v", err, test.wantErr)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("error = %v, want %v", err, test.err)
+			}
+
+			if got := sortedList(writable); !reflect.DeepEqual(got, test.writable) {
+				t.Errorf("writable layer = %v, want %v", got, test.writable)
+			}
+			if got, want := sortedList(catalogStore), []Entry{catalog, team}; !reflect.DeepEqual(got, want) {
+				t.Errorf("catalog layer = %v, want %v", got, want)
+			}
+			if got := sortedList(l); !reflect.DeepEqual(got, test.list) {
+				t.Errorf("List() = %v, want %v", got, test.list)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Memory keeps the git profiles in memory only, saving does nothing. It is
+// used as the in-memory representation of every other store and by the
+// tests, it can't be selected as a database driver.
+type Memory struct {
+	entries map[string]string
+}
+
+func NewMemory(entries ...Entry) *Memory {
+	m := &Memory{entries: make(map[string]string)}
+	for _, entry := range entries {
+		m.entries[entry.Name] = entry.Email
+	}
+
+	return m
+}
+
+func (m *Memory) List() []Entry {
+	entries := make([]Entry, 0)
+	for name, email := range m.entries {
+		entries = append(entries, Entry{Name: name, Email: email})
+	}
+
+	return entries
+}
+
+func (m *Memory) Get(name string) (Entry, error) {
+	if _, ok := m.entries[name]; !ok {
+		return Entry{}, notFound(fmt.Sprintf("no entry with the name %s exists", name))
+	}
+
+	return Entry{Name: name, Email: m.entries[name]}, nil
+}
+
+func (m *Memory) Add(user Entry) error {
+	if user.IsIncomplete() {
+		return errors.New("cannot add incomplete user to the database")
+	}
+
+	if _, ok := m.entries[user.Name]; ok {
+		return fmt.Errorf("an entry with the name %s already exists", user.Name)
+	}
+
+	m.entries[user.Name] = user.Email
+
+	return nil
+}
+
+func (m *Memory) Update(prev, curr Entry) error {
+	if _, ok := m.entries[prev.Name]; !ok {
+		return notFound(fmt.Sprintf("no entry with the name %s exists", prev.Name))
+	}
+
+	if curr.Name != prev.Name {
+		if _, ok := m.entries[curr.Name]; ok {
+			return fmt.Errorf("cannot update name to %s as it already exists", curr.Name)
+		}
+		delete(m.entries, prev.Name)
+	}
+
+	m.entries[curr.Name] = curr.Email
+
+	return nil
+}
+
+func (m *Memory) Delete(name string) error {
+	if _, ok := m.entries[name]; !ok {
+		return notFound(fmt.Sprintf("no entry with the name %s exists", name))
+	}
+
+	delete(m.entries, name)
+
+	return nil
+}
+
+func (m *Memory) Print() {
+	for _, entry := range m.List() {
+		fmt.Printf("name=%s, email=%s\n", entry.Name, entry.Email)
+	}
+}
+
+func (m *Memory) Save() error {
+	return nil
+}
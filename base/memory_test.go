@@ -0,0 +1,70 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"errors"
+	"sort"
+	"testing"
+)
+
+func TestMemory(t *testing.T) {
+	alice := Entry{Name: "alice", Email: "alice@example.com"}
+	bob := Entry{Name: "bob", Email: "bob@example.com"}
+
+	tests := []struct {
+		name    string
+		op      func(m *Memory) error
+		want    []Entry
+		wantErr bool
+		err     error
+	}{
+		{"add", func(m *Memory) error { return m.Add(bob) }, []Entry{alice, bob}, false, nil},
+		{"add existing", func(m *Memory) error { return m.Add(Entry{Name: "alice", Email: "other@example.com"}) }, []Entry{alice}, true, nil},
+		{"add incomplete", func(m *Memory) error { return m.Add(Entry{Name: "bob"}) }, []Entry{alice}, true, nil},
+		{"update email", func(m *Memory) error { return m.Update(alice, Entry{Name: "alice", Email: "new@example.com"}) }, []Entry{{Name: "alice", Email: "new@example.com"}}, false, nil},
+		{"rename", func(m *Memory) error { return m.Update(alice, Entry{Name: "carol", Email: alice.Email}) }, []Entry{{Name: "carol", Email: alice.Email}}, false, nil},
+		{"update missing", func(m *Memory) error { return m.Update(bob, bob) }, []Entry{alice}, true, ErrNotFound},
+		{"delete", func(m *Memory) error { return m.Delete("alice") }, []Entry{}, false, nil},
+		{"delete missing", func(m *Memory) error { return m.Delete("bob") }, []Entry{alice}, true, ErrNotFound},
+		{"get missing", func(m *Memory) error { _, err := m.Get("bob"); return err }, []Entry{alice}, true, ErrNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewMemory(alice)
+
+			err := test.op(m)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("error = %v, want error %v", err, test.wantErr)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Errorf("error = %v, want %v", err, test.err)
+			}
+
+			got := m.List()
+			sort.Slice(got, func(i, j int) bool { return got[i].Name < got[j].Name })
+			if len(got) != len(test.want) {
+				t.Fatalf("List() = %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("List() = %v, want %v", got, test.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tabarnhack/git-switch/io/print"
+)
+
+// AmbiguousError is returned when a query matches several profiles, its
+// message lists the candidates
+type AmbiguousError struct {
+	Query   string
+	Matches []Entry
+}
+
+func (e *AmbiguousError) Error() string {
+	candidates := make([]string, 0, len(e.Matches))
+	for _, entry := range e.Matches {
+		candidates = append(candidates, fmt.Sprintf("%s <%s>", entry.Name, entry.Email))
+	}
+
+	return fmt.Sprintf("%s matches several profiles: %s", e.Query, strings.Join(candidates, ", "))
+}
+
+// Resolve returns the profile designated by the query: the profile with this
+// exact name, else the only one whose name starts with it, else the only one
+// whose name or email contains it, ignoring case. An AmbiguousError listing
+// the candidates is returned when several profiles match.
+func Resolve(store ProfileStore, query string) (Entry, error) {
+	if entry, err := store.Get(query); err == nil {
+		print.Debug("Resolved", query, "to the profile with this exact name")
+		return entry, nil
+	}
+
+	entries := store.List()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	q := strings.ToLower(query)
+	rules := []struct {
+		name  string
+		match func(Entry) bool
+	}{
+		{"name ignoring case", func(e Entry) bool { return strings.ToLower(e.Name) == q }},
+		{"name prefix", func(e Entry) bool { return strings.HasPrefix(strings.ToLower(e.Name), q) }},
+		{"name or email substring", func(e Entry) bool {
+			return strings.Contains(strings.ToLower(e.Name), q) || strings.Contains(strings.ToLower(e.Email), q)
+		}},
+	}
+
+	for _, rule := range rules {
+		var matches []Entry
+		for _, entry := range entries {
+			if rule.match(entry) {
+				matches = append(matches, entry)
+			}
+		}
+		print.Trace("Rule", rule.name, "matches", len(matches), "profiles for", query)
+
+		switch len(matches) {
+		case 0:
+			continue
+		case 1:
+			print.Debug("Resolved", query, "to", matches[0].Name, "by", rule.name)
+			return matches[0], nil
+		}
+		return Entry{}, &AmbiguousError{Query: query, Matches: matches}
+	}
+
+	return Entry{}, notFound(fmt.Sprintf("no profile matches %s", query))
+}
@@ -0,0 +1,99 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	store := NewMemory(
+		Entry{Name: "work", Email: "jane@corp.com"},
+		Entry{Name: "Work", Email: "jane@other.com"},
+		Entry{Name: "workshop", Email: "jane@shop.com"},
+		Entry{Name: "home", Email: "jane@home.org"},
+		Entry{Name: "homelab", Email: "lab@home.org"},
+		Entry{Name: "oss", Email: "jane@users.noreply.github.com"},
+	)
+
+	tests := []struct {
+		query     string
+		want      string
+		ambiguous []string
+		err       error
+	}{
+		// The exact name wins over every other rule
+		{query: "work", want: "work"},
+		{query: "Work", want: "Work"},
+		{query: "home", want: "home"},
+		// Then the name ignoring case
+		{query: "WORKSHOP", want: "workshop"},
+		{query: "WORK", ambiguous: []string{"Work", "work"}},
+		// Then the name prefix
+		{query: "works", want: "workshop"},
+		{query: "homel", want: "homelab"},
+		{query: "hom", ambiguous: []string{"home", "homelab"}},
+		// Then the name or email substring
+		{query: "github", want: "oss"},
+		{query: "LAB@", want: "homelab"},
+		{query: "home.org", ambiguous: []string{"home", "homelab"}},
+		{query: "jane@", ambiguous: []string{"Work", "home", "oss", "work", "workshop"}},
+		{query: "nobody", err: ErrNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			got, err := Resolve(store, test.query)
+
+			var ambiguous *AmbiguousError
+			switch {
+			case test.ambiguous != nil:
+				if !errors.As(err, &ambiguous) {
+					t.Fatalf("Resolve() error = %v, want an AmbiguousError", err)
+				}
+				var names []string
+				for _, entry := range ambiguous.Matches {
+					names = append(names, entry.Name)
+				}
+				if !reflect.DeepEqual(names, test.ambiguous) {
+					t.Errorf("Resolve() matches %v, want %v", names, test.ambiguous)
+				}
+			case test.err != nil:
+				if !errors.Is(err, test.err) {
+					t.Errorf("Resolve() error = %v, want %v", err, test.err)
+				}
+			case err != nil:
+				t.Fatalf("Resolve() error = %v", err)
+			case got.Name != test.want:
+				t.Errorf("Resolve() = %s, want %s", got.Name, test.want)
+			}
+		})
+	}
+}
+
+func TestAmbiguousError(t *testing.T) {
+	err := &AmbiguousError{Query: "wo", Matches: []Entry{
+		{Name: "Work", Email: "jane@other.com"},
+		{Name: "work", Email: "jane@corp.com"},
+	}}
+
+	want := "wo matches several profiles: Work <jane@other.com>, work <jane@corp.com>"
+	if got := err.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package base
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tabarnhack/git-switch/config"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/io/prompt"
+)
+
+const (
+	BoltDriver = "bolt"
+	YAMLDriver = "yaml"
+	TOMLDriver = "toml"
+
+	PassphraseEnv = "GIT_SWITCH_PASSPHRASE"
+)
+
+var (
+	ErrReadOnly = errors.New("the database has been opened in read-only mode")
+	ErrNotFound = errors.New("profile not found")
+)
+
+// notFound is the error about a missing profile, matching ErrNotFound
+type notFound string
+
+func (e notFound) Error() string {
+	return string(e)
+}
+
+func (e notFound) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ProfileStore is implemented by every git profiles database backend
+type ProfileStore interface {
+	List() []Entry
+	Get(name string) (Entry, error)
+	Add(user Entry) error
+	Update(prev, curr Entry) error
+	Delete(name string) error
+	Save() error
+}
+
+// Names returns the names of the profiles of the store
+func Names(store ProfileStore) []string {
+	entries := store.List()
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name)
+	}
+
+	return names
+}
+
+// Open loads the git profiles database with the backend selected by the
+// driver key, or guessed from the database file extension. Unless a path is
+// given, every database found in the search paths is loaded as a layer.
+// A read-only database is never created, it is empty if it can't be found.
+func Open(conf config.DatabaseConfig, readOnly bool) (ProfileStore, error) {
+	driver := Driver(conf)
+	if conf.Path != "" {
+		if _, err := os.Stat(conf.Path); err != nil && readOnly {
+			print.Debug("No database at", conf.Path, "reading it as empty")
+			return NewMemory(), nil
+		}
+		print.Debug("Opening the", driver, "database", conf.Path)
+		return openStore(conf, driver, conf.Path, readOnly)
+	}
+
+	dirs := conf.SearchPaths
+	if len(dirs) == 0 {
+		return nil, errors.New("no database search path configured")
+	}
+
+	// The first layer is the user's database, the only one written to
+	var writable ProfileStore
+	var err error
+
+	path := filepath.Join(dirs[0], conf.Filename)
+	print.Trace("Looking for the user's database at", path)
+	if _, statErr := os.Stat(path); statErr == nil {
+		print.Debug("Opening the", driver, "database", path)
+		writable, err = openStore(conf, driver, path, readOnly)
+	} else if readOnly {
+		print.Debug("No database at", path, "reading it as empty")
+		writable = NewMemory()
+	} else if path, err = createDB(conf, dirs[0]); err == nil {
+		writable, err = openStore(conf, driver, path, readOnly)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	layers := []Layer{{Path: path, Store: writable}}
+	for _, dir := range dirs[1:] {
+		path := filepath.Join(dir, conf.Filename)
+		print.Trace("Looking for a catalog at", path)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+
+		print.Debug("Opening the read-only catalog", path)
+		store, err := openStore(conf, driver, path, true)
+		if err != nil {
+			return nil, fmt.Errorf("cannot load %s: %w", path, err)
+		}
+		layers = append(layers, Layer{Path: path, Store: store})
+	}
+
+	return NewLayered(layers...), nil
+}
+
+func openStore(conf config.DatabaseConfig, driver, path string, readOnly bool) (ProfileStore, error) {
+	switch driver {
+	case BoltDriver:
+		return New(path, readOnly, Passphrase(conf))
+	case YAMLDriver, TOMLDriver:
+		return NewFile(path, driver, readOnly)
+	}
+
+	return nil, fmt.Errorf("unknown database driver %s", driver)
+}
+
+// Passphrase returns the unlocker reading the passphrase of an encrypted
+// database from the environment, the key file or by prompting the user
+func Passphrase(conf config.DatabaseConfig) Unlocker {
+	return func() ([]byte, error) {
+		if passphrase, ok := os.LookupEnv(PassphraseEnv); ok {
+			return []byte(passphrase), nil
+		}
+
+		if conf.KeyFile != "" {
+			return ReadKeyFile(conf.KeyFile)
+		}
+
+		passphrase, err := prompt.PromptPassword("Database passphrase")
+		return []byte(passphrase), prompt.WithFlag(err, PassphraseEnv+" or --key-file")
+	}
+}
+
+// ReadKeyFile returns the passphrase stored inside a key file
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	return bytes.TrimRight(data, "\r\n"), err
+}
+
+// Driver returns the backend used for the database
+func Driver(conf config.DatabaseConfig) string {
+	if conf.Driver != "" {
+		return strings.ToLower(conf.Driver)
+	}
+
+	filename := conf.Path
+	if filename == "" {
+		filename = conf.Filename
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yml", ".yaml":
+		return YAMLDriver
+	case ".toml":
+		return TOMLDriver
+	}
+
+	return BoltDriver
+}
+
+func createDB(conf config.DatabaseConfig, dir string) (string, error) {
+	create, err := prompt.Confirm("No database has been found. Do you want to create one")
+	if err != nil {
+		return "", err
+	}
+
+	if !create {
+		return "", fmt.Errorf("Cannot find user database inside %s", dir)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("Cannot create user database in %s: %s", dir, err)
+	}
+
+	path := filepath.Join(dir, conf.Filename)
+	print.Info("User database created at:", path)
+	return path, nil
+}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/config"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/io/prompt"
+)
+
+func registerCompletion(cmd *cobra.Command, flag string, fn func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective)) {
+	if err := cmd.RegisterFlagCompletionFunc(flag, fn); err != nil {
+		panic(err)
+	}
+}
+
+// completeValues completes with a fixed list of values
+func completeValues(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeProfiles completes the names of the git profiles, described by
+// their emails. The config is read without side effects, the DB is opened
+// read-only and nothing is prompted or printed, an encrypted DB completing
+// nothing without its passphrase.
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	print.DisableOutput()
+	prompt.SetMode(false, false)
+
+	c, _, err := config.Read(cfgFile)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	if profilesBase != "" {
+		c.Database.Path = profilesBase
+	}
+	if keyFile != "" {
+		c.Database.KeyFile = keyFile
+	}
+	if err := c.Expand(); err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	db, err := base.Open(c.Database, true)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	entries := db.List()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	var names []string
+	for _, entry := range entries {
+		if strings.HasPrefix(strings.ToLower(entry.Name), strings.ToLower(toComplete)) {
+			names = append(names, entry.Name+"\t"+entry.Email)
+		}
+	}
+
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfileArg completes the profile given as the only argument
+func completeProfileArg(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return completeProfiles(cmd, args, toComplete)
+}
+
+// completeConfigKeys completes the first argument with the configuration keys
+func completeConfigKeys(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	return config.SortedKeys(), cobra.ShellCompDirectiveNoFileComp
+}
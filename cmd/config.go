@@ -0,0 +1,337 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tabarnhack/git-switch/config"
+	"github.com/tabarnhack/git-switch/io/print"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	forceInit  bool
+	showOrigin bool
+)
+
+// configValueResult is the value of a configuration key. Origin is only
+// set when requested.
+type configValueResult struct {
+	Key    string      `json:"key" yaml:"key"`
+	Value  interface{} `json:"value" yaml:"value"`
+	Origin string      `json:"origin,omitempty" yaml:"origin,omitempty"`
+}
+
+func (r configValueResult) Rows() print.TableData {
+	return print.TableData{{"Key", "Value"}, {r.Key, config.Format(r.Value)}}
+}
+
+func (r configValueResult) Present() {
+	fmt.Println(config.Format(r.Value))
+}
+
+// configListResult lists every configuration key
+type configListResult struct {
+	Keys       []configValueResult `json:"keys" yaml:"keys"`
+	showOrigin bool
+}
+
+func (r configListResult) Rows() print.TableData {
+	header := []string{"Key", "Value"}
+	if r.showOrigin {
+		header = append(header, "Origin")
+	}
+
+	data := print.TableData{header}
+	for _, k := range r.Keys {
+		row := []string{k.Key, config.Format(k.Value)}
+		if r.showOrigin {
+			row = append(row, k.Origin)
+		}
+		data = append(data, row)
+	}
+
+	return data
+}
+
+// configShowResult is the configuration in use
+type configShowResult config.Config
+
+func (r configShowResult) Rows() print.TableData {
+	c := config.Config(r)
+	data := print.TableData{{"Key", "Value"}}
+	for _, key := range config.SortedKeys() {
+		value, _ := c.Get(key)
+		data = append(data, []string{key, config.Format(value)})
+	}
+
+	return data
+}
+
+func (r configShowResult) Present() {
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(r); err != nil {
+		print.Error("Can't encode config:", err)
+		os.Exit(exitCode(err))
+	}
+	enc.Close()
+}
+
+// configEditResult is the outcome of an edit of the config file. Changed is
+// false when the file has been left untouched.
+type configEditResult struct {
+	File    string `json:"file" yaml:"file"`
+	Key     string `json:"key,omitempty" yaml:"key,omitempty"`
+	Value   string `json:"value,omitempty" yaml:"value,omitempty"`
+	Changed bool   `json:"changed" yaml:"changed"`
+}
+
+func (r configEditResult) Rows() print.TableData {
+	return print.TableData{
+		{"File", "Key", "Value", "Changed"},
+		{r.File, r.Key, r.Value, strconv.FormatBool(r.Changed)},
+	}
+}
+
+func (r configEditResult) Present() {
+	switch {
+	case !r.Changed:
+		print.Notice(r.Key, "is not set in", r.File)
+	case r.Key == "":
+		print.Success("Config file written at", r.File)
+	default:
+		print.Success("Config file saved at", r.File)
+	}
+}
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the git-switch configuration",
+	Long: `Read and edit the git-switch config file. Keys are
+written as dotted paths, eg. database.filename. The
+config file is edited in place, keeping its comments.`,
+}
+
+// configInitCmd represents the config init command
+var configInitCmd = &cobra.Command{
+	Use:              "init",
+	Short:            "Write a commented config file with the default values",
+	PersistentPreRun: editConfig,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := configFilePath()
+		if _, err := os.Stat(path); err == nil && !forceInit {
+			print.Error("A config file already exists at", path, "(use --force to overwrite it)")
+			os.Exit(exitUsage)
+		}
+
+		defaults, _, err := config.New()
+		if err != nil {
+			print.Error("Can't initialize config:", err)
+			os.Exit(exitCode(err))
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			print.Error("Can't create config directory:", err)
+			os.Exit(exitCode(err))
+		}
+
+		if err := os.WriteFile(path, defaults.Template(), 0600); err != nil {
+			print.Error("Can't write config file:", err)
+			os.Exit(exitCode(err))
+		}
+
+		render(configEditResult{File: path, Changed: true})
+	},
+}
+
+// configGetCmd represents the config get command
+var configGetCmd = &cobra.Command{
+	Use:              "get <key>",
+	Short:            "Print the value of a configuration key",
+	Args:             cobra.ExactArgs(1),
+	PersistentPreRun: preRun(noDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		value, err := conf.Get(args[0])
+		if err != nil {
+			print.Error(err)
+			os.Exit(exitCode(err))
+		}
+
+		render(configValueResult{Key: strings.ToLower(args[0]), Value: value})
+	},
+}
+
+// configSetCmd represents the config set command
+var configSetCmd = &cobra.Command{
+	Use:              "set <key> <value>",
+	Short:            "Set a configuration key in the config file",
+	Long:             `Set a configuration key in the config file. Lists are given comma separated.`,
+	Args:             cobra.ExactArgs(2),
+	PersistentPreRun: editConfig,
+	Run: func(cmd *cobra.Command, args []string) {
+		f := loadConfigFile()
+		if err := f.Set(args[0], args[1]); err != nil {
+			print.Error("Can't set config key:", err)
+			os.Exit(exitCode(err))
+		}
+
+		saveConfigFile(f)
+		render(configEditResult{File: f.Path(), Key: strings.ToLower(args[0]), Value: args[1], Changed: true})
+	},
+}
+
+// configUnsetCmd represents the config unset command
+var configUnsetCmd = &cobra.Command{
+	Use:              "unset <key>",
+	Short:            "Remove a configuration key from the config file",
+	Args:             cobra.ExactArgs(1),
+	PersistentPreRun: editConfig,
+	Run: func(cmd *cobra.Command, args []string) {
+		f := loadConfigFile()
+		removed, err := f.Unset(args[0])
+		if err != nil {
+			print.Error("Can't unset config key:", err)
+			os.Exit(exitCode(err))
+		}
+
+		if removed {
+			saveConfigFile(f)
+		}
+		render(configEditResult{File: f.Path(), Key: strings.ToLower(args[0]), Changed: removed})
+	},
+}
+
+// configShowCmd represents the config show command
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Print the configuration in use",
+	Long: `Print the configuration in use as YAML, once the
+config file, the environment and the flags have
+been applied.`,
+	PersistentPreRun: preRun(noDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		render(configShowResult(*conf))
+	},
+}
+
+// configListCmd represents the config list command
+var configListCmd = &cobra.Command{
+	Use:              "list",
+	Short:            "List every configuration key with its value",
+	PersistentPreRun: preRun(noDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		var f *config.File
+		if showOrigin && viper.ConfigFileUsed() != "" {
+			f = loadConfigFile()
+		}
+
+		result := configListResult{showOrigin: showOrigin}
+		for _, key := range config.SortedKeys() {
+			value, _ := conf.Get(key)
+			k := configValueResult{Key: key, Value: value}
+			if showOrigin {
+				k.Origin = configOrigin(f, key)
+			}
+			result.Keys = append(result.Keys, k)
+		}
+
+		render(result)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configInitCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configShowCmd)
+
+	for _, cmd := range []*cobra.Command{configGetCmd, configSetCmd, configUnsetCmd} {
+		cmd.ValidArgsFunction = completeConfigKeys
+	}
+
+	configInitCmd.PersistentFlags().BoolVarP(&forceInit, "force", "f", false, "overwrite the existing config file")
+	configListCmd.PersistentFlags().BoolVar(&showOrigin, "show-origin", false, "show where each value comes from")
+}
+
+// editConfig initializes the commands editing the config file, which must
+// run even if the configuration is invalid in order to fix it
+func editConfig(cmd *cobra.Command, args []string) {
+	initConfig(false)
+}
+
+// configFilePath returns the config file to edit: the one given or loaded,
+// or else the user's config file
+func configFilePath() string {
+	if cfgFile != "" {
+		return cfgFile
+	}
+
+	if used := viper.ConfigFileUsed(); used != "" {
+		return used
+	}
+
+	return filepath.Join(configPaths[0], config.ConfigName+".yml")
+}
+
+func loadConfigFile() *config.File {
+	f, err := config.LoadFile(configFilePath())
+	if err != nil {
+		print.Error("Can't load config file:", err)
+		os.Exit(exitCode(err))
+	}
+
+	return f
+}
+
+func saveConfigFile(f *config.File) {
+	if err := f.Save(); err != nil {
+		print.Error("Can't save config file:", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// configOrigin returns where the value of a configuration key comes from,
+// f being the config file loaded if any
+func configOrigin(f *config.File, key string) string {
+	switch {
+	case key == "database.path" && profilesBase != "":
+		return "flag --db"
+	case key == "database.keyfile" && keyFile != "":
+		return "flag --key-file"
+	}
+
+	if _, ok := os.LookupEnv(config.EnvName(key)); ok {
+		return "env " + config.EnvName(key)
+	}
+
+	if f != nil && f.Has(key) {
+		return "file " + f.Path()
+	}
+
+	return "default"
+}
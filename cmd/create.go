@@ -17,6 +17,7 @@ package cmd
 
 import (
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 	"github.com/tabarnhack/git-switch/io/print"
@@ -25,6 +26,37 @@ import (
 
 var autoAdd bool
 
+// createResult is the git profile created, Saved being false on a dry run.
+// Switch is the outcome of the switch to the profile with --auto-add.
+type createResult struct {
+	Profile *profileResult `json:"profile" yaml:"profile"`
+	Saved   bool           `json:"saved" yaml:"saved"`
+	Switch  *switchResult  `json:"switch,omitempty" yaml:"switch,omitempty"`
+}
+
+func (r createResult) Rows() print.TableData {
+	header := []string{"Name", "Email", "Saved"}
+	row := []string{r.Profile.Name, r.Profile.Email, strconv.FormatBool(r.Saved)}
+	if r.Switch != nil {
+		header = append(header, "Gitconfig", "Written")
+		row = append(row, r.Switch.Gitconfig, strconv.FormatBool(r.Switch.Written))
+	}
+
+	return print.TableData{header, row}
+}
+
+func (r createResult) Present() {
+	if r.Saved {
+		print.Success("User created")
+	} else {
+		print.Notice("Dry run, the user has not been saved:", r.Profile)
+	}
+
+	if r.Switch != nil {
+		r.Switch.Present()
+	}
+}
+
 // createCmd represents the create command
 var createCmd = &cobra.Command{
 	Use:   "create",
@@ -34,30 +66,43 @@ inside the git profiles DB. Each of the profile must
 have a unique name in order to differentiate each
 other. The newly created git profile can also be 
 automatically added as the current git profile.`,
+	PersistentPreRun: preRun(writeDB),
 	Run: func(cmd *cobra.Command, args []string) {
-		currUser, err := user.CreateUser(currUser, false)
+		var err error
+		currUser, err = user.CreateUser(currUser, false)
 		if err != nil {
 			print.Error("Can't get user information:", err)
-			os.Exit(1)
+			os.Exit(exitCode(err))
+		}
+
+		// The values given by --name and --email are not prompted
+		if err := currUser.Validate(); err != nil {
+			print.Error("Invalid user information:", err)
+			os.Exit(exitUsage)
 		}
 
 		err = usersDB.Add(currUser)
 		if err != nil {
 			print.Error("Can't add user to database:", err)
-			os.Exit(1)
+			os.Exit(exitCode(err))
 		}
 
-		err = usersDB.Save()
-		if err != nil {
-			print.Error("Can't save new user:", err)
-			os.Exit(1)
+		result := createResult{Profile: newProfileResult(currUser)}
+		if !dryRun {
+			err = usersDB.Save()
+			if err != nil {
+				print.Error("Can't save new user:", err)
+				os.Exit(exitCode(err))
+			}
+			result.Saved = true
 		}
 
-		print.Success("User created")
-
 		if autoAdd {
-			switchCmd.Run(cmd, args)
+			switched := switchProfile()
+			result.Switch = &switched
 		}
+
+		render(result)
 	},
 }
 
@@ -67,4 +112,5 @@ func init() {
 	createCmd.PersistentFlags().StringVar(&currUser.Name, "name", "", "new user's name")
 	createCmd.PersistentFlags().StringVar(&currUser.Email, "email", "", "new user's email")
 	createCmd.PersistentFlags().BoolVarP(&autoAdd, "auto-add", "a", false, "automatically switch the profile to the one created")
+	addPreviewFlags(createCmd)
 }
@@ -0,0 +1,190 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/io/prompt"
+)
+
+var newKeyFile string
+
+// dbResult is the state of the git profiles DB once changed
+type dbResult struct {
+	Action    string `json:"action" yaml:"action"`
+	Encrypted bool   `json:"encrypted" yaml:"encrypted"`
+}
+
+func (r dbResult) Rows() print.TableData {
+	return print.TableData{
+		{"Action", "Encrypted"},
+		{r.Action, strconv.FormatBool(r.Encrypted)},
+	}
+}
+
+func (r dbResult) Present() {
+	switch r.Action {
+	case "encrypt":
+		print.Success("Database encrypted")
+	case "decrypt":
+		print.Success("Database decrypted")
+	case "rekey":
+		print.Success("Database passphrase changed")
+	}
+}
+
+// dbCmd represents the db command
+var dbCmd = &cobra.Command{
+	Use:   "db",
+	Short: "Manage the git profiles DB",
+}
+
+// dbEncryptCmd represents the db encrypt command
+var dbEncryptCmd = &cobra.Command{
+	Use:   "encrypt",
+	Short: "Encrypt the git profiles DB",
+	Long: `Seal every git profile of the DB with a key derived
+from a passphrase. The passphrase is read from the
+GIT_SWITCH_PASSPHRASE environment variable, the key
+file or prompted. It will be needed to unlock the DB
+on each run. Only the emails are sealed, the profile
+names stay in plain text.`,
+	PersistentPreRun: preRun(writeDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		b := boltDB()
+		if b.Encrypted() {
+			print.Error("The database is already encrypted, use rekey to change its passphrase")
+			os.Exit(exitUsage)
+		}
+
+		passphrase, err := base.Passphrase(conf.Database)()
+		if err == nil && os.Getenv(base.PassphraseEnv) == "" && conf.Database.KeyFile == "" {
+			err = confirmPassphrase(passphrase)
+		}
+		if err != nil {
+			print.Error("Can't get passphrase:", err)
+			os.Exit(exitCode(err))
+		}
+
+		if err := b.Encrypt(passphrase); err != nil {
+			print.Error("Can't encrypt database:", err)
+			os.Exit(exitCode(err))
+		}
+
+		render(dbResult{Action: "encrypt", Encrypted: true})
+	},
+}
+
+// dbDecryptCmd represents the db decrypt command
+var dbDecryptCmd = &cobra.Command{
+	Use:   "decrypt",
+	Short: "Decrypt the git profiles DB",
+	Long: `Store every git profile of the DB in plain text.
+The DB won't need a passphrase anymore.`,
+	PersistentPreRun: preRun(writeDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := boltDB().Decrypt(); err != nil {
+			print.Error("Can't decrypt database:", err)
+			os.Exit(exitCode(err))
+		}
+
+		render(dbResult{Action: "decrypt", Encrypted: false})
+	},
+}
+
+// dbRekeyCmd represents the db rekey command
+var dbRekeyCmd = &cobra.Command{
+	Use:   "rekey",
+	Short: "Change the passphrase of the git profiles DB",
+	Long: `Seal again every git profile of the encrypted DB
+with a new passphrase. The new passphrase is read
+from the file given by --new-key-file or prompted.`,
+	PersistentPreRun: preRun(writeDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		b := boltDB()
+		if !b.Encrypted() {
+			print.Error("The database is not encrypted, use encrypt instead")
+			os.Exit(exitUsage)
+		}
+
+		var passphrase []byte
+		var err error
+
+		if newKeyFile != "" {
+			passphrase, err = base.ReadKeyFile(newKeyFile)
+		} else {
+			var input string
+			input, err = prompt.PromptPassword("New database passphrase")
+			err = prompt.WithFlag(err, "--new-key-file")
+			passphrase = []byte(input)
+			if err == nil {
+				err = confirmPassphrase(passphrase)
+			}
+		}
+
+		if err != nil {
+			print.Error("Can't get new passphrase:", err)
+			os.Exit(exitCode(err))
+		}
+
+		if err := b.Encrypt(passphrase); err != nil {
+			print.Error("Can't change database passphrase:", err)
+			os.Exit(exitCode(err))
+		}
+
+		render(dbResult{Action: "rekey", Encrypted: true})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dbCmd)
+	dbCmd.AddCommand(dbEncryptCmd)
+	dbCmd.AddCommand(dbDecryptCmd)
+	dbCmd.AddCommand(dbRekeyCmd)
+
+	dbRekeyCmd.PersistentFlags().StringVar(&newKeyFile, "new-key-file", "", "file containing the new passphrase")
+}
+
+// boltDB returns the loaded database if it supports encryption
+func boltDB() *base.Base {
+	b, ok := writableDB().(*base.Base)
+	if !ok {
+		print.Error("Encryption is only supported by the", base.BoltDriver, "database driver")
+		os.Exit(exitUsage)
+	}
+
+	return b
+}
+
+func confirmPassphrase(passphrase []byte) error {
+	confirm, err := prompt.PromptPassword("Confirm passphrase")
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(passphrase, []byte(confirm)) {
+		return errors.New("passphrases do not match")
+	}
+
+	return nil
+}
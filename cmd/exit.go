@@ -0,0 +1,59 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/io/prompt"
+	"github.com/tabarnhack/git-switch/journal"
+)
+
+// Exit codes, distinct for each class of failure so that scripts can tell
+// them apart
+const (
+	exitFailure  = 1   // any failure not listed below
+	exitUsage    = 2   // invalid flags, arguments or configuration, or an ambiguous profile
+	exitNoInput  = 3   // an answer is needed while prompting is disabled
+	exitNotFound = 4   // the profile, backup or file does not exist
+	exitConflict = 5   // the DB has been changed by another process
+	exitLocked   = 6   // the passphrase of the DB is wrong
+	exitAborted  = 130 // the user interrupted a prompt
+)
+
+// exitCode returns the exit code of the failure class of the error
+func exitCode(err error) int {
+	var noInput *prompt.NoInputError
+	var ambiguous *base.AmbiguousError
+	switch {
+	case errors.As(err, &noInput):
+		return exitNoInput
+	case errors.As(err, &ambiguous):
+		return exitUsage
+	case errors.Is(err, prompt.ErrAborted):
+		return exitAborted
+	case errors.Is(err, base.ErrNotFound), errors.Is(err, journal.ErrNotFound), errors.Is(err, os.ErrNotExist):
+		return exitNotFound
+	case errors.Is(err, base.ErrConflict):
+		return exitConflict
+	case errors.Is(err, base.ErrWrongPassphrase):
+		return exitLocked
+	}
+
+	return exitFailure
+}
@@ -0,0 +1,97 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/transfer"
+)
+
+var (
+	exportFormat string
+	exportNames  []string
+	exportFile   string
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export git profiles from the DB",
+	Long: `Write the git profiles of the DB to the standard
+output, or to a file, as JSON, YAML or CSV. The
+exported file can be imported on another machine
+with the import command.`,
+	PersistentPreRun: preRun(readDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		var entries []base.Entry
+		if len(exportNames) == 0 {
+			entries = usersDB.List()
+			sort.Slice(entries, func(i, j int) bool {
+				return entries[i].Name < entries[j].Name
+			})
+		} else {
+			for _, name := range exportNames {
+				entry, err := usersDB.Get(name)
+				if err != nil {
+					print.Error("Can't export user:", err)
+					os.Exit(exitCode(err))
+				}
+				entries = append(entries, entry)
+			}
+		}
+
+		w := os.Stdout
+		if exportFile != "" {
+			f, err := os.OpenFile(exportFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+			if err != nil {
+				print.Error("Can't create export file:", err)
+				os.Exit(exitCode(err))
+			}
+			defer f.Close()
+			w = f
+		}
+
+		// Without --format, the profiles follow the output format when it
+		// can be imported back
+		format := exportFormat
+		if format == "" {
+			format = transfer.JSON
+			if print.Output() == print.YAMLOutput {
+				format = transfer.YAML
+			}
+		}
+
+		if err := transfer.Encode(w, format, entries); err != nil {
+			print.Error("Can't export users:", err)
+			os.Exit(exitCode(err))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.PersistentFlags().StringVar(&exportFormat, "format", "", "export format (json, yaml or csv), json unless --output is yaml")
+	exportCmd.PersistentFlags().StringVar(&exportFile, "file", "", "file to export to, the standard output is used by default")
+	exportCmd.PersistentFlags().StringSliceVar(&exportNames, "name", nil, "name of a user to export, every user is exported if none is given")
+	registerCompletion(exportCmd, "name", completeProfiles)
+	registerCompletion(exportCmd, "format", completeValues(transfer.Formats...))
+}
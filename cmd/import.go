@@ -0,0 +1,382 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/gitconfig"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/io/prompt"
+	"github.com/tabarnhack/git-switch/transfer"
+)
+
+var (
+	importFormat  string
+	onConflict    string
+	fromGitconfig bool
+	scanDirs      []string
+	importAll     bool
+	fromLog       string
+	topCandidates int
+	fromSwitcher  string
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import <file|-> | --from-gitconfig [paths...] | --from-log <repo> | --from <tool> [path]",
+	Short: "Import git profiles into the DB",
+	Long: `Read git profiles from a JSON, YAML or CSV file, or
+from the standard input with "-", and add them to
+the DB. Each profile is validated as if it had been
+created with the create command. A profile whose
+name already exists with another email is handled
+according to --on-conflict.
+
+With --from-gitconfig, the git profiles are instead
+collected from gitconfig files and their includes,
+and from the repositories found by --scan. Each new
+profile is confirmed unless --all is given.
+
+With --from-log, the authors and committers of the
+repository history are suggested instead, the most
+frequent and recent first. A name committed with several
+emails is suffixed with a number from its second email.
+
+With --from, the profiles stored by another identity
+switcher (git-switcher, gitego or json) are migrated,
+read from the tool's default location if no path is
+given. Fields with no equivalent are reported.`,
+	PersistentPreRun: preRun(writeDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		strategy, err := transfer.ParseStrategy(onConflict)
+		if err != nil {
+			print.Error(err)
+			os.Exit(exitUsage)
+		}
+
+		if topCandidates < 1 {
+			print.Error("--top expects at least 1 user, got", topCandidates)
+			os.Exit(exitUsage)
+		}
+
+		if fromSwitcher != "" {
+			importEntries(migrateSwitcher(fromSwitcher, args), strategy)
+			return
+		}
+
+		if fromLog != "" {
+			importEntries(logCandidates(fromLog), strategy)
+			return
+		}
+
+		if fromGitconfig || len(scanDirs) > 0 {
+			importEntries(harvestGitconfigs(args), strategy)
+			return
+		}
+
+		if len(args) != 1 {
+			print.Error("Expected one file to import, got", len(args))
+			os.Exit(exitUsage)
+		}
+
+		format := importFormat
+		if format == "" {
+			var ok bool
+			if format, ok = transfer.FormatFromFilename(args[0]); !ok {
+				format = transfer.JSON
+			}
+		}
+
+		var r io.Reader = os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				print.Error("Can't open import file:", err)
+				os.Exit(exitCode(err))
+			}
+			defer f.Close()
+			r = f
+		}
+
+		entries, err := transfer.Decode(r, format)
+		if err != nil {
+			print.Error("Can't read import file:", err)
+			os.Exit(exitCode(err))
+		}
+
+		importEntries(entries, strategy)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+
+	importCmd.PersistentFlags().StringVar(&importFormat, "format", "", "import format (json, yaml or csv), guessed from the file extension by default")
+	importCmd.PersistentFlags().BoolVar(&fromGitconfig, "from-gitconfig", false, "collect users from gitconfig files, the default ones if no path is given")
+	importCmd.PersistentFlags().StringSliceVar(&scanDirs, "scan", nil, "directory to search for git repositories whose config is collected")
+	importCmd.PersistentFlags().StringVar(&fromLog, "from-log", "", "suggest users from the commit history of a repository")
+	importCmd.PersistentFlags().IntVar(&topCandidates, "top", 5, "number of users suggested from the commit history")
+	importCmd.PersistentFlags().StringVar(&fromSwitcher, "from", "", "migrate the profiles of another identity switcher (git-switcher, gitego or json)")
+	importCmd.PersistentFlags().BoolVar(&importAll, "all", false, "import every collected user without confirmation")
+	importCmd.PersistentFlags().StringVar(&onConflict, "on-conflict", string(transfer.Fail), "what to do when a different user with the same name exists (skip, overwrite, rename or fail)")
+
+	strategies := make([]string, 0, len(transfer.Strategies))
+	for _, strategy := range transfer.Strategies {
+		strategies = append(strategies, string(strategy))
+	}
+	tools := make([]string, 0, len(transfer.Adapters))
+	for _, adapter := range transfer.Adapters {
+		tools = append(tools, adapter.Name())
+	}
+	registerCompletion(importCmd, "format", completeValues(transfer.Formats...))
+	registerCompletion(importCmd, "on-conflict", completeValues(strategies...))
+	registerCompletion(importCmd, "from", completeValues(tools...))
+	if err := importCmd.MarkPersistentFlagDirname("scan"); err != nil {
+		panic(err)
+	}
+	if err := importCmd.MarkPersistentFlagDirname("from-log"); err != nil {
+		panic(err)
+	}
+}
+
+// harvestGitconfigs returns the users found in gitconfig files which are not
+// already in the DB, confirmed by the user unless every one is imported
+func harvestGitconfigs(paths []string) []base.Entry {
+	if len(paths) == 0 && len(scanDirs) == 0 {
+		paths = gitconfig.DefaultPaths()
+		if _, err := os.Stat(localGitconfigPath()); err == nil {
+			paths = append(paths, localGitconfigPath())
+		}
+	}
+
+	for _, dir := range scanDirs {
+		found, err := gitconfig.Scan(dir)
+		if err != nil {
+			print.Error("Can't scan directory:", err)
+			os.Exit(exitCode(err))
+		}
+		paths = append(paths, found...)
+	}
+
+	identities, err := gitconfig.Harvest(paths)
+	if err != nil {
+		print.Error("Can't read gitconfig file:", err)
+		os.Exit(exitCode(err))
+	}
+
+	var entries []base.Entry
+	var sources []string
+	for _, identity := range gitconfig.Unknown(usersDB, identities) {
+		entries = append(entries, identity.Entry)
+		sources = append(sources, "found in "+identity.Filename)
+	}
+
+	return confirmImports(entries, sources)
+}
+
+// logCandidates returns the best ranked identities of the repository history
+// whose email is not already in the DB, the names shared by several of them
+// being suffixed, confirmed by the user unless every one is imported
+func logCandidates(repo string) []base.Entry {
+	candidates, err := transfer.FromLog(repo)
+	if err != nil {
+		print.Error("Can't read repository history:", err)
+		os.Exit(exitCode(err))
+	}
+
+	known := make(map[string]bool)
+	for _, entry := range usersDB.List() {
+		known[strings.ToLower(entry.Email)] = true
+	}
+
+	var entries []base.Entry
+	var sources []string
+	for _, c := range candidates {
+		if len(entries) == topCandidates {
+			break
+		}
+
+		if known[strings.ToLower(c.Email)] {
+			continue
+		}
+
+		entries = append(entries, c.Entry)
+		sources = append(sources, fmt.Sprintf("with %d commits, last on %s", c.Commits, c.Last.Format("2006-01-02")))
+	}
+
+	// The same name is often committed with several emails
+	return confirmImports(transfer.Disambiguate(usersDB, entries), sources)
+}
+
+// migrateSwitcher returns the profiles of another identity switcher which are
+// not already in the DB, confirmed by the user unless every one is imported
+func migrateSwitcher(tool string, args []string) []base.Entry {
+	adapter, err := transfer.AdapterByName(tool)
+	if err != nil {
+		print.Error(err)
+		os.Exit(exitUsage)
+	}
+
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	}
+
+	profiles, err := transfer.Migrate(adapter, path)
+	if err != nil {
+		print.Error("Can't read", tool, "profiles:", err)
+		os.Exit(exitCode(err))
+	}
+
+	var entries []base.Entry
+	var sources []string
+	for _, p := range profiles {
+		if len(p.Unmapped) > 0 {
+			print.Info(fmt.Sprintf("Fields of the %s profile %s that can't be migrated: %s", tool, p.Source, strings.Join(p.Unmapped, ", ")))
+		}
+
+		if existing, err := usersDB.Get(p.Name); err == nil && existing == p.Entry {
+			continue
+		}
+
+		entries = append(entries, p.Entry)
+		sources = append(sources, fmt.Sprintf("from the %s profile %s", tool, p.Source))
+	}
+
+	return confirmImports(entries, sources)
+}
+
+// confirmImports asks the user which users to import, unless every one is
+// imported. Nothing being selected is reported, the empty import summary
+// following.
+func confirmImports(entries []base.Entry, sources []string) []base.Entry {
+	var selected []base.Entry
+	for i, entry := range entries {
+		if !importAll {
+			confirm, err := prompt.Confirm(fmt.Sprintf("Import %s %s", entry, sources[i]))
+			err = prompt.WithFlag(err, "--all or --yes")
+			if err != nil {
+				print.Error("Cannot get user confirmation:", err)
+				os.Exit(exitCode(err))
+			}
+			if !confirm {
+				continue
+			}
+		}
+
+		selected = append(selected, entry)
+	}
+
+	if len(selected) == 0 {
+		print.Notice("No new user to import")
+	}
+
+	return selected
+}
+
+// importResult is the outcome of an import, every profile being listed
+// under what happened to it
+type importResult struct {
+	Added       []profileResult `json:"added" yaml:"added"`
+	Overwritten []profileResult `json:"overwritten" yaml:"overwritten"`
+	Renamed     []profileResult `json:"renamed" yaml:"renamed"`
+	Skipped     []profileResult `json:"skipped" yaml:"skipped"`
+	Unchanged   []profileResult `json:"unchanged" yaml:"unchanged"`
+	Invalid     []invalidResult `json:"invalid" yaml:"invalid"`
+}
+
+// invalidResult is an imported profile rejected by the validation
+type invalidResult struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+	Error string `json:"error" yaml:"error"`
+}
+
+func newImportResult(report transfer.Report) importResult {
+	profiles := func(entries []base.Entry) []profileResult {
+		results := make([]profileResult, 0, len(entries))
+		for _, entry := range entries {
+			results = append(results, profileResult{Name: entry.Name, Email: entry.Email})
+		}
+		return results
+	}
+
+	result := importResult{
+		Added:       profiles(report.Added),
+		Overwritten: profiles(report.Overwritten),
+		Renamed:     profiles(report.Renamed),
+		Skipped:     profiles(report.Skipped),
+		Unchanged:   profiles(report.Unchanged),
+		Invalid:     make([]invalidResult, 0, len(report.Invalid)),
+	}
+	for _, invalid := range report.Invalid {
+		result.Invalid = append(result.Invalid, invalidResult{Name: invalid.Entry.Name, Email: invalid.Entry.Email, Error: invalid.Err.Error()})
+	}
+
+	return result
+}
+
+func (r importResult) Rows() print.TableData {
+	data := print.TableData{[]string{"Status", "Name", "Email"}}
+	rows := func(status string, profiles []profileResult) {
+		for _, p := range profiles {
+			data = append(data, []string{status, p.Name, p.Email})
+		}
+	}
+	rows("added", r.Added)
+	rows("overwritten", r.Overwritten)
+	rows("renamed", r.Renamed)
+	rows("skipped", r.Skipped)
+	rows("unchanged", r.Unchanged)
+	for _, invalid := range r.Invalid {
+		data = append(data, []string{"invalid: " + invalid.Error, invalid.Name, invalid.Email})
+	}
+
+	return data
+}
+
+func (r importResult) Present() {
+	if data := r.Rows(); len(data) > 1 {
+		print.Table(data)
+	}
+
+	print.Success(fmt.Sprintf("%d added, %d overwritten, %d renamed, %d skipped, %d unchanged, %d invalid",
+		len(r.Added), len(r.Overwritten), len(r.Renamed),
+		len(r.Skipped), len(r.Unchanged), len(r.Invalid)))
+}
+
+// importEntries adds the entries to the DB and prints a summary of the import
+func importEntries(entries []base.Entry, strategy transfer.Strategy) {
+	report, err := transfer.Import(usersDB, entries, strategy)
+	if err != nil {
+		print.Error("Can't import users:", err)
+		os.Exit(exitCode(err))
+	}
+
+	err = usersDB.Save()
+	if err != nil {
+		print.Error("Can't save imported users:", err)
+		os.Exit(exitCode(err))
+	}
+
+	render(newImportResult(report))
+}
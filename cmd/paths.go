@@ -0,0 +1,147 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/tabarnhack/git-switch/config"
+	"github.com/tabarnhack/git-switch/io/print"
+)
+
+// pathsResult lists the paths git-switch reads or writes
+type pathsResult struct {
+	ConfigFiles []pathResult `json:"config_files" yaml:"config_files"`
+	Databases   []pathResult `json:"databases" yaml:"databases"`
+	Gitconfig   string       `json:"gitconfig" yaml:"gitconfig"`
+	Backups     string       `json:"backups" yaml:"backups"`
+	Sync        string       `json:"sync" yaml:"sync"`
+}
+
+// pathResult is a file with its status: missing, found or used. Writable
+// is only set for the databases.
+type pathResult struct {
+	Path     string `json:"path" yaml:"path"`
+	Status   string `json:"status" yaml:"status"`
+	Writable *bool  `json:"writable,omitempty" yaml:"writable,omitempty"`
+}
+
+func (r pathsResult) Rows() print.TableData {
+	data := print.TableData{{"Usage", "Path", "Status"}}
+	for _, p := range r.ConfigFiles {
+		data = append(data, []string{"config", p.Path, p.Status})
+	}
+	for _, p := range r.Databases {
+		data = append(data, []string{"database", p.Path, p.describe()})
+	}
+
+	return append(data,
+		[]string{"gitconfig", r.Gitconfig, ""},
+		[]string{"backups", r.Backups, ""},
+		[]string{"sync", r.Sync, ""},
+	)
+}
+
+func (r pathsResult) Present() {
+	print.Section("Config files")
+	data := print.TableData{{"Path", "Status"}}
+	for _, p := range r.ConfigFiles {
+		data = append(data, []string{p.Path, p.Status})
+	}
+	print.Table(data)
+
+	print.Section("Git profiles databases")
+	data = print.TableData{{"Path", "Status"}}
+	for _, p := range r.Databases {
+		data = append(data, []string{p.Path, p.describe()})
+	}
+	print.Table(data)
+
+	print.Section("Other paths")
+	print.Table(print.TableData{
+		{"Usage", "Path"},
+		{"gitconfig", r.Gitconfig},
+		{"backups", r.Backups},
+		{"sync", r.Sync},
+	})
+}
+
+func (p pathResult) describe() string {
+	switch {
+	case p.Writable == nil:
+		return p.Status
+	case *p.Writable:
+		return p.Status + ", writable"
+	}
+	return p.Status + ", read-only"
+}
+
+// pathsCmd represents the paths command
+var pathsCmd = &cobra.Command{
+	Use:   "paths",
+	Short: "Print the resolved paths",
+	Long: `Print every path git-switch reads or writes, once
+the XDG environment variables, the config file and
+the flags have been taken into account.`,
+	PersistentPreRun: preRun(noDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		result := pathsResult{
+			Gitconfig: gitconfigFile,
+			Backups:   conf.Backup.Path,
+			Sync:      conf.Sync.Path,
+		}
+
+		if cfgFile != "" {
+			result.ConfigFiles = append(result.ConfigFiles, pathResult{Path: cfgFile, Status: "used"})
+		} else {
+			for _, dir := range configPaths {
+				path := filepath.Join(dir, config.ConfigName+".yml")
+				result.ConfigFiles = append(result.ConfigFiles, pathResult{Path: path, Status: pathStatus(path, path == viper.ConfigFileUsed())})
+			}
+		}
+
+		if conf.Database.Path != "" {
+			writable := true
+			result.Databases = append(result.Databases, pathResult{Path: conf.Database.Path, Status: pathStatus(conf.Database.Path, true), Writable: &writable})
+		} else {
+			for i, dir := range conf.Database.SearchPaths {
+				path := filepath.Join(dir, conf.Database.Filename)
+				writable := i == 0
+				result.Databases = append(result.Databases, pathResult{Path: path, Status: pathStatus(path, false), Writable: &writable})
+			}
+		}
+
+		render(result)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pathsCmd)
+}
+
+func pathStatus(path string, used bool) string {
+	if _, err := os.Stat(path); err != nil {
+		return "missing"
+	}
+
+	if used {
+		return "used"
+	}
+	return "found"
+}
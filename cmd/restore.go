@@ -0,0 +1,172 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/io/prompt"
+	"github.com/tabarnhack/git-switch/journal"
+)
+
+var (
+	listBackups bool
+	backupID    int
+)
+
+// backupsResult lists the backups of the gitconfig files, newest first
+type backupsResult struct {
+	Backups []backupResult `json:"backups" yaml:"backups"`
+}
+
+// backupResult is a backup of a gitconfig file
+type backupResult struct {
+	ID   int       `json:"id" yaml:"id"`
+	Path string    `json:"path" yaml:"path"`
+	Time time.Time `json:"time" yaml:"time"`
+}
+
+func (r backupsResult) Rows() print.TableData {
+	data := print.TableData{[]string{"ID", "Date", "File"}}
+	for _, b := range r.Backups {
+		data = append(data, []string{fmt.Sprint(b.ID), b.Time.Format("2006-01-02 15:04:05"), b.Path})
+	}
+
+	return data
+}
+
+// restoreResult is the outcome of a restore. Restored is false when the file
+// has been left untouched, eg. on a dry run.
+type restoreResult struct {
+	Backup   backupResult `json:"backup" yaml:"backup"`
+	Restored bool         `json:"restored" yaml:"restored"`
+}
+
+func (r restoreResult) Rows() print.TableData {
+	return print.TableData{
+		{"ID", "File", "Restored"},
+		{fmt.Sprint(r.Backup.ID), r.Backup.Path, strconv.FormatBool(r.Restored)},
+	}
+}
+
+func (r restoreResult) Present() {
+	if r.Restored {
+		print.Success("Restored", r.Backup.Path, "from backup", r.Backup.ID)
+	}
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore a gitconfig file from a backup",
+	Long: `A backup of every gitconfig file is taken before
+git-switch writes to it. This rolls a file back to
+one of those backups, by default the most recent
+backup of the selected gitconfig file. The changes
+are shown and confirmed before being applied.`,
+	PersistentPreRun: preRun(noDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		if listBackups {
+			records, err := backups.List()
+			if err != nil {
+				print.Error("Can't list backups:", err)
+				os.Exit(exitCode(err))
+			}
+
+			result := backupsResult{Backups: make([]backupResult, 0, len(records))}
+			for _, r := range records {
+				result.Backups = append(result.Backups, backupResult{ID: r.ID, Path: r.Path, Time: r.Time})
+			}
+
+			render(result)
+			return
+		}
+
+		var r journal.Record
+		var content []byte
+		var err error
+
+		if backupID != 0 {
+			r, content, err = backups.Get(backupID)
+		} else {
+			r, content, err = backups.Latest(gitconfigFile)
+		}
+
+		if err != nil {
+			print.Error("Can't load backup:", err)
+			os.Exit(exitCode(err))
+		}
+
+		result := restoreResult{Backup: backupResult{ID: r.ID, Path: r.Path, Time: r.Time}}
+
+		curr, err := os.ReadFile(r.Path)
+		if err != nil {
+			print.Error("Can't read gitconfig file:", err)
+			os.Exit(exitCode(err))
+		}
+
+		changed, err := print.Diff(r.Path, fmt.Sprintf("%s (backup %d)", r.Path, r.ID), curr, content)
+		if err != nil {
+			print.Error("Can't compute changes:", err)
+			os.Exit(exitCode(err))
+		}
+
+		if !changed {
+			print.Notice("The gitconfig file already matches the backup")
+			render(result)
+			return
+		}
+
+		if dryRun {
+			print.Notice("Dry run, nothing has been written to", r.Path)
+			render(result)
+			return
+		}
+
+		confirm, err := prompt.Confirm("Do you want to apply these changes")
+		if err != nil {
+			print.Error("Cannot get user confirmation:", err)
+			os.Exit(exitCode(err))
+		}
+
+		if !confirm {
+			render(result)
+			return
+		}
+
+		err = backups.Restore(r, content)
+		if err != nil {
+			print.Error("Can't restore gitconfig file:", err)
+			os.Exit(exitCode(err))
+		}
+
+		result.Restored = true
+		render(result)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(restoreCmd)
+
+	restoreCmd.PersistentFlags().BoolVar(&listBackups, "list", false, "list the available backups")
+	restoreCmd.PersistentFlags().IntVar(&backupID, "id", 0, "id of the backup to restore")
+	restoreCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show the changes to the gitconfig file without writing them")
+}
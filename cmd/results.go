@@ -0,0 +1,44 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/tabarnhack/git-switch/base"
+)
+
+// profileResult is a git profile in the command results
+type profileResult struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+}
+
+// newProfileResult returns the profile result of the entry, nil if the entry
+// is empty
+func newProfileResult(entry base.Entry) *profileResult {
+	if entry.IsEmpty() {
+		return nil
+	}
+
+	return &profileResult{Name: entry.Name, Email: entry.Email}
+}
+
+func (p *profileResult) String() string {
+	if p == nil {
+		return ""
+	}
+
+	return base.Entry{Name: p.Name, Email: p.Email}.String()
+}
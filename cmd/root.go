@@ -16,14 +16,20 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/tabarnhack/git-switch/base"
 	"github.com/tabarnhack/git-switch/config"
+	"github.com/tabarnhack/git-switch/gitconfig"
 	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/io/prompt"
+	"github.com/tabarnhack/git-switch/journal"
 
 	homedir "github.com/mitchellh/go-homedir"
 )
@@ -37,14 +43,41 @@ const (
 var (
 	cfgFile       string
 	profilesBase  string
+	keyFile       string
 	gitconfigFile string
 
 	systemGitconfig bool
 	globalGitConfig bool
 	localGitconfig  bool
 
-	usersDB  *base.Base
-	currUser base.Entry
+	dryRun   bool
+	showDiff bool
+
+	outputFormat string
+	assumeYes    bool
+	noInput      bool
+	answersFile  string
+	accessible   bool
+
+	verbosity int
+	quiet     bool
+	logFile   string
+
+	conf        *config.Config
+	configPaths []string
+	usersDB     base.ProfileStore
+	currUser    base.Entry
+
+	backups *journal.Journal
+)
+
+// dbAccess is the access to the git profiles database a command requires
+type dbAccess int
+
+const (
+	noDB dbAccess = iota
+	readDB
+	writeDB
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -59,61 +92,166 @@ var rootCmd = &cobra.Command{
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
 		print.Error(err)
-		os.Exit(1)
+		os.Exit(exitUsage)
 	}
 }
 
 func init() {
-	cobra.OnInitialize(initConfig)
-
-	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is $HOME/.config/git-switch/config.yml)")
+	rootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is $XDG_CONFIG_HOME/git-switch/config.yml)")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", print.TableOutput, "output format of the results (table, json, yaml or tsv)")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to every confirmation (env GIT_SWITCH_YES)")
+	rootCmd.PersistentFlags().BoolVar(&accessible, "accessible", false, "prompt with numbered menus and plain text, without colors (default when TERM is dumb)")
+	rootCmd.PersistentFlags().StringVar(&answersFile, "answers", "", "YAML file answering the prompts in order, \"-\" to read it from the standard input")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "show the resolution steps on the standard error, -vv for every path and rule tried")
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "only show the warnings and errors on the standard error")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "append the diagnostics to the file, every write to a gitconfig file included")
+	rootCmd.PersistentFlags().BoolVar(&noInput, "no-input", false, "never prompt, fail when an answer is missing (env GIT_SWITCH_NO_INPUT, default when the input is not a terminal)")
 
 	rootCmd.PersistentFlags().StringVarP(&profilesBase, "db", "d", "", "git profiles database")
+	rootCmd.PersistentFlags().StringVar(&keyFile, "key-file", "", "file containing the passphrase of an encrypted git profiles database")
 
 	rootCmd.PersistentFlags().StringVar(&gitconfigFile, "gitconfig", "", "gitconfig file to use")
 	rootCmd.PersistentFlags().BoolVarP(&systemGitconfig, "system", "s", false, "modify gitconfig at system level (eg. /etc/git/gitconfig)")
 	rootCmd.PersistentFlags().BoolVarP(&globalGitConfig, "global", "g", false, "modify gitconfig at global level (eg. $HOME/.gitconfig)")
 	rootCmd.PersistentFlags().BoolVarP(&localGitconfig, "local", "l", false, "modify gitconfig at local level (eg. $PWD/.git/config)")
+
+	for _, flag := range []string{"config", "db", "key-file", "gitconfig", "answers", "log-file"} {
+		if err := rootCmd.MarkPersistentFlagFilename(flag); err != nil {
+			panic(err)
+		}
+	}
+	registerCompletion(rootCmd, "output", completeValues(print.OutputFormats...))
+}
+
+// preRun returns the hook initializing what a command needs before it runs.
+// The database is only opened, and created if needed, by commands writing to it.
+func preRun(access dbAccess) func(cmd *cobra.Command, args []string) {
+	return func(cmd *cobra.Command, args []string) {
+		initConfig(true)
+
+		if access != noDB {
+			initDB(access == readDB)
+		}
+	}
 }
 
-// initConfig reads in config file and ENV variables if set.
-func initConfig() {
-	conf, configPaths, err := config.New()
+// initConfig reads in config file and ENV variables if set. An invalid
+// configuration is fatal when strict, it is only reported otherwise.
+func initConfig(strict bool) {
+	var err error
+
+	initLogging()
+
+	// The config may enable it too, checked once it is loaded
+	if accessibleMode() {
+		print.DisableStyling()
+	}
+
+	if err := print.SetOutput(outputFormat); err != nil {
+		print.Error(err)
+		os.Exit(exitUsage)
+	}
+
+	conf, configPaths, err = config.New()
 	if err != nil {
 		print.Error("Can't initialize config:", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
 		for _, path := range configPaths {
+			print.Trace("Looking for the config file in", path)
 			viper.AddConfigPath(path)
 		}
 		viper.SetConfigName(config.ConfigName)
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
+	// Every key can be set from the environment, eg. database.filename
+	// with GIT_SWITCH_DATABASE_FILENAME
+	viper.SetEnvPrefix(config.EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	for key := range config.Keys {
+		if err := viper.BindEnv(key); err != nil {
+			print.Error("Can't read environment:", err)
+			os.Exit(exitCode(err))
+		}
+		if _, ok := os.LookupEnv(config.EnvName(key)); ok {
+			print.Debug("Config key", key, "is set by", config.EnvName(key))
+		}
+	}
 	viper.SetConfigType("yml")
 
-	// If a config file is found, read it in.
+	// If a config file is found, read it in. Without one, the defaults are used.
 	if err := viper.ReadInConfig(); err != nil {
-		print.Error("Can't read config:", err)
-		os.Exit(1)
+		_, notFound := err.(viper.ConfigFileNotFoundError)
+		switch {
+		case notFound && cfgFile == "":
+		case !strict && errors.Is(err, os.ErrNotExist):
+			// The config commands create the file given by --config
+			print.Debug("The config file", cfgFile, "does not exist yet")
+		default:
+			print.Error("Can't read config:", err)
+			os.Exit(exitUsage)
+		}
 	}
 
-	if err := viper.Unmarshal(&conf); err != nil {
-		print.Error("Unable to decode into struct, %v", err)
-		os.Exit(1)
+	if paths, ok := config.LegacySearchPaths(viper.Get("database.searchpaths")); ok {
+		print.Warn("database.searchpaths is a map of directories by layer, which is deprecated, set it to the list", config.Format(paths))
+		viper.Set("database.searchpaths", paths)
 	}
 
-	print.Info("Using config file:", viper.ConfigFileUsed())
-	fmt.Printf("%#v\n", conf)
+	// The settings are only decoded once they are known to be valid
+	problems := config.CheckSettings(viper.AllSettings())
+	if len(problems) == 0 {
+		// A configured list replaces the default one instead of being merged into it
+		if viper.IsSet("database.searchpaths") {
+			conf.Database.SearchPaths = nil
+		}
+
+		if err := viper.Unmarshal(&conf); err != nil {
+			print.Error("Unable to decode into struct:", err)
+			os.Exit(exitCode(err))
+		}
+	}
+
+	if profilesBase != "" {
+		conf.Database.Path = profilesBase
+	}
+
+	if keyFile != "" {
+		conf.Database.KeyFile = keyFile
+	}
+
+	if err := conf.Expand(); err != nil {
+		print.Error("Can't expand config paths:", err)
+		os.Exit(exitCode(err))
+	}
+
+	if viper.ConfigFileUsed() != "" {
+		print.Debug("Using config file:", viper.ConfigFileUsed())
+	} else {
+		print.Debug("No config file found, using default config")
+	}
+
+	problems = append(problems, conf.Validate()...)
+	if len(problems) > 0 {
+		reportConfig(problems)
+		if strict {
+			os.Exit(exitUsage)
+		}
+	}
+
+	if accessibleMode() {
+		print.DisableStyling()
+	}
+	initPrompts()
 
 	if systemGitconfig {
 		if gitconfigFile != "" {
 			print.Error("Can't specify multiple gitconfig files")
-			os.Exit(1)
+			os.Exit(exitUsage)
 		}
 		gitconfigFile = SYSTEM_GITCONFIG
 	}
@@ -121,12 +259,12 @@ func initConfig() {
 	if globalGitConfig {
 		if gitconfigFile != "" {
 			print.Error("Can't specify multiple gitconfig files")
-			os.Exit(1)
+			os.Exit(exitUsage)
 		}
 		home, err := homedir.Dir()
 		if err != nil {
 			print.Error("Can't get home directory:", err)
-			os.Exit(1)
+			os.Exit(exitCode(err))
 		}
 		gitconfigFile = home + GLOBAL_GITCONFIG
 	}
@@ -134,29 +272,206 @@ func initConfig() {
 	if localGitconfig {
 		if gitconfigFile != "" {
 			print.Error("Can't specify multiple gitconfig files")
-			os.Exit(1)
+			os.Exit(exitUsage)
 		}
-		pwd, err := os.Getwd()
-		if err != nil {
-			print.Error("Can't get working directory:", err)
-			os.Exit(1)
-		}
-		gitconfigFile = pwd + LOCAL_GITCONFIG
+		gitconfigFile = localGitconfigPath()
 	}
 
 	// if gitconfigFile is empty, we load a default value
 	if gitconfigFile == "" {
 		gitconfigFile = conf.DefaultGitconfig
-		print.Info("No gitconfig file provided. Using default:", gitconfigFile)
+		print.Debug("No gitconfig file provided. Using default:", gitconfigFile)
+	} else {
+		print.Debug("Using gitconfig file:", gitconfigFile)
 	}
 
-	if profilesBase != "" {
-		conf.Database.Path = profilesBase
+	backups, err = journal.New(conf.Backup)
+	if err != nil {
+		print.Error("Can't load gitconfig backups:", err)
+		os.Exit(exitCode(err))
 	}
+}
 
-	usersDB, err = base.New(conf.Database)
+// initLogging selects the diagnostics shown from the verbosity flags, and
+// where they are logged
+func initLogging() {
+	switch {
+	case quiet && verbosity > 0:
+		print.Error("Can't be both quiet and verbose")
+		os.Exit(exitUsage)
+	case quiet:
+		print.SetLevel(print.WarnLevel)
+	case verbosity == 1:
+		print.SetLevel(print.DebugLevel)
+	case verbosity > 1:
+		print.SetLevel(print.TraceLevel)
+	}
+
+	if logFile != "" {
+		if err := print.OpenLogFile(logFile); err != nil {
+			print.Error("Can't open log file:", err)
+			os.Exit(exitCode(err))
+		}
+	}
+}
+
+// reportConfig prints the problems of the configuration along with where
+// each invalid value comes from
+func reportConfig(problems []config.Problem) {
+	var f *config.File
+	if viper.ConfigFileUsed() != "" {
+		f, _ = config.LoadFile(viper.ConfigFileUsed())
+	}
+
+	for _, p := range problems {
+		print.Error(fmt.Sprintf("Invalid config key %s (%s): %s", p.Key, configOrigin(f, p.Key), p.Message))
+	}
+	print.Info("Fix the config with \"git-switch config set <key> <value>\" or \"git-switch config unset <key>\"")
+}
+
+// initPrompts selects how the user is prompted. Prompts are disabled when
+// nobody can answer them, unless the answers are scripted.
+func initPrompts() {
+	yes := assumeYes || envBool(config.EnvPrefix+"_YES")
+	interactive := !noInput && !envBool(config.EnvPrefix+"_NO_INPUT") && prompt.IsTerminal()
+
+	// Output for humans goes to the standard error with the machine-readable formats
+	var out *os.File = os.Stdout
+	if print.Machine() {
+		out = os.Stderr
+	}
+
+	switch {
+	case answersFile != "":
+		r := os.Stdin
+		if answersFile != "-" {
+			f, err := os.Open(answersFile)
+			if err != nil {
+				print.Error("Can't open answers file:", err)
+				os.Exit(exitUsage)
+			}
+			defer f.Close()
+			r = f
+		}
+
+		script, err := prompt.LoadScript(r)
+		if err != nil {
+			print.Error("Can't load answers file:", err)
+			os.Exit(exitUsage)
+		}
+		prompt.SetPrompter(script)
+		interactive = true
+	case accessibleMode():
+		prompt.SetPrompter(prompt.NewPlain(os.Stdin, out))
+	default:
+		p := prompt.NewPromptui(os.Stdin, out)
+		p.PageSize = conf.Selector.PageSize
+		prompt.SetPrompter(p)
+	}
+
+	prompt.SetMode(interactive, yes)
+}
+
+// accessibleMode reports whether plain text has to be used instead of the
+// interactive widgets and the colors
+func accessibleMode() bool {
+	return accessible || (conf != nil && conf.Accessible) || os.Getenv("TERM") == "dumb"
+}
+
+// envBool reports whether the environment variable is set to true
+func envBool(name string) bool {
+	value, err := strconv.ParseBool(os.Getenv(name))
+	return err == nil && value
+}
+
+// render prints the result of a command in the selected output format
+func render(result print.Result) {
+	if err := print.Render(result); err != nil {
+		print.Error("Can't render result:", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+// initDB loads the git profiles database. In read-only mode, the database is
+// never created and a missing database is seen as empty.
+func initDB(readOnly bool) {
+	var err error
+
+	usersDB, err = base.Open(conf.Database, readOnly)
 	if err != nil {
 		print.Error("Can't load user database:", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// addPreviewFlags registers the flags of commands writing to a gitconfig file
+func addPreviewFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "show the changes to the gitconfig file without writing them")
+	cmd.PersistentFlags().BoolVar(&showDiff, "diff", false, "show the changes to the gitconfig file and confirm them before writing")
+}
+
+// saveGitconfig writes the gitconfig file, previewing the changes first if
+// requested. It returns false when nothing has been written.
+func saveGitconfig(g *gitconfig.Gitconfig) (bool, error) {
+	if !g.Changed() {
+		if dryRun || showDiff {
+			print.Notice("No changes to write to", g.Filename())
+		}
+		return false, nil
 	}
+
+	if dryRun || showDiff {
+		curr, err := os.ReadFile(g.Filename())
+		if err != nil {
+			return false, err
+		}
+
+		next, err := g.Render()
+		if err != nil {
+			return false, err
+		}
+
+		changed, err := print.Diff(g.Filename(), g.Filename()+" (new)", curr, next)
+		if err != nil {
+			return false, err
+		}
+
+		if !changed {
+			print.Notice("No changes to write to", g.Filename())
+			return false, nil
+		}
+
+		if dryRun {
+			print.Notice("Dry run, nothing has been written to", g.Filename())
+			return false, nil
+		}
+
+		confirm, err := prompt.Confirm("Do you want to apply these changes")
+		if err != nil || !confirm {
+			return false, err
+		}
+	}
+
+	return true, g.Save()
+}
+
+// localGitconfigPath returns the gitconfig of the repository in the working directory
+func localGitconfigPath() string {
+	pwd, err := os.Getwd()
+	if err != nil {
+		print.Error("Can't get working directory:", err)
+		os.Exit(exitCode(err))
+	}
+
+	return pwd + LOCAL_GITCONFIG
+}
+
+// writableDB returns the database changes are written to, without the
+// read-only layers
+func writableDB() base.ProfileStore {
+	if layered, ok := usersDB.(*base.Layered); ok {
+		return layered.Writable()
+	}
+
+	return usersDB
 }
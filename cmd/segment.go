@@ -0,0 +1,91 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/gitconfig"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/io/prompt"
+	"github.com/tabarnhack/git-switch/segment"
+)
+
+var segmentFormat string
+
+// segmentCmd represents the prompt-segment command
+var segmentCmd = &cobra.Command{
+	Use:   "prompt-segment",
+	Short: "Print the git profile of the current repository for the shell prompt",
+	Long: `Print the identity git commits with in the current
+repository, to be shown in the shell prompt (PS1,
+starship...). Nothing is printed outside repositories.
+
+The format is a Go template with the fields .Profile
+(the name of the profile of the DB matching the
+identity, empty if none does), .Name, .Email and .Scope
+(system, global or local).
+
+The command is kept fast by reading the config file
+without the environment, opening the DB read-only and
+caching the result until a gitconfig, config or DB file
+changes. An encrypted DB is only read when its
+passphrase is given by GIT_SWITCH_PASSPHRASE or the key
+file.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		tmpl, err := template.New("segment").Parse(segmentFormat)
+		if err != nil {
+			print.Error("Invalid format:", err)
+			os.Exit(exitUsage)
+		}
+
+		dir, err := os.Getwd()
+		if err != nil {
+			os.Exit(exitFailure)
+		}
+
+		gitDir, ok := gitconfig.FindGitDir(dir)
+		if !ok {
+			return
+		}
+
+		// The prompt is redrawn without anyone to answer
+		prompt.SetMode(false, false)
+
+		// Errors are not reported, they would be printed in every prompt
+		s, err := segment.Get(gitDir, cfgFile)
+		if err != nil {
+			os.Exit(exitCode(err))
+		}
+		if s.Name == "" && s.Email == "" {
+			return
+		}
+
+		if err := tmpl.Execute(os.Stdout, s); err != nil {
+			print.Error("Invalid format:", err)
+			os.Exit(exitUsage)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(segmentCmd)
+
+	segmentCmd.Flags().StringVar(&segmentFormat, "format", "{{or .Profile .Email}}", "Go template of the segment")
+}
@@ -16,9 +16,13 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/base"
 	"github.com/tabarnhack/git-switch/gitconfig"
 	"github.com/tabarnhack/git-switch/io/print"
 	"github.com/tabarnhack/git-switch/io/prompt"
@@ -30,68 +34,175 @@ var (
 	forceSwitch  bool
 )
 
+// switchResult is the outcome of a switch of git profile. Written is false
+// when the gitconfig file has been left untouched, eg. on a dry run.
+type switchResult struct {
+	Gitconfig string         `json:"gitconfig" yaml:"gitconfig"`
+	Previous  *profileResult `json:"previous" yaml:"previous"`
+	Profile   *profileResult `json:"profile" yaml:"profile"`
+	Written   bool           `json:"written" yaml:"written"`
+}
+
+func (r switchResult) Rows() print.TableData {
+	return print.TableData{
+		{"Gitconfig", "Previous", "Profile", "Written"},
+		{r.Gitconfig, r.Previous.String(), r.Profile.String(), strconv.FormatBool(r.Written)},
+	}
+}
+
+func (r switchResult) Present() {
+	if r.Written {
+		print.Success("Selected user:", r.Profile)
+	}
+}
+
 // switchCmd represents the switch command
 var switchCmd = &cobra.Command{
-	Use:   "switch",
+	Use:   "switch [profile]",
 	Short: "Switch the git profile used in gitconfig",
 	Long: `Change the git profile of the corresponding gitconfig
 file with the one selected from the DB. The existing
-git profile can be saved before being overwritten.`,
+git profile can be saved before being overwritten.
+
+The profile may be given by the start of its name,
+or by a part of its name or email, as long as only
+one profile matches. Otherwise the matching profiles
+are prompted.`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeProfileArg,
+	PersistentPreRun:  preRun(writeDB),
 	Run: func(cmd *cobra.Command, args []string) {
-		g, err := gitconfig.New(gitconfigFile, true)
-		if err != nil {
-			print.Error("Can't load gitconfig file:", err)
-			os.Exit(1)
+		if len(args) > 0 {
+			if currUser.Name != "" {
+				print.Error("Can't give the profile both as argument and with --name")
+				os.Exit(exitUsage)
+			}
+			currUser.Name = args[0]
 		}
 
-		if g.Entry.IsEmpty() {
-			print.Info("Currently, no git profile is set inside this file")
+		render(switchProfile())
+	},
+}
+
+// switchProfile writes the selected git profile to the gitconfig file
+func switchProfile() switchResult {
+	g, err := gitconfig.New(gitconfigFile, true)
+	if err != nil {
+		print.Error("Can't load gitconfig file:", err)
+		os.Exit(exitCode(err))
+	}
+	g.Journal = backups
+
+	result := switchResult{Gitconfig: g.Filename(), Previous: newProfileResult(g.Entry)}
+
+	if g.Entry.IsEmpty() {
+		print.Notice("Currently, no git profile is set inside this file")
+	} else {
+		print.Notice("The current profile for this gitconfig file is", g.Entry)
+
+		// There is nothing to save when the profile is already stored as is
+		if stored, err := usersDB.Get(g.Entry.Name); err == nil && stored == g.Entry {
+			print.Debug("The current profile is already stored in the DB")
 		} else {
-			print.Info("The current profile for this gitconfig file is", g.Entry)
 			if !saveExisting && !forceSwitch {
 				saveExisting, err = prompt.Confirm("Do you want to save the current git profile")
+				err = prompt.WithFlag(err, "--save, --force or --yes")
 				if err != nil {
 					print.Error("Cannot get user confirmation:", err)
-					os.Exit(1)
+					os.Exit(exitCode(err))
 				}
 			}
 
 			if saveExisting {
-				err = usersDB.Add(g.Entry)
-				if err != nil {
-					print.Error("Can't add user to database:", err)
-					os.Exit(1)
-				}
+				saveCurrentProfile(g.Entry)
 			}
 		}
-
-		if currUser.Name == "" {
-			currUser, err = user.SelectUser(usersDB, "Switch user")
-		} else {
-			currUser, err = usersDB.Get(currUser.Name)
-		}
-
-		if err != nil {
-			print.Error("Can't select user:", err)
-			os.Exit(1)
+	}
+
+	sel := user.Selection{Current: g.Entry}
+	sel.LastUsed, err = backups.LastUsed()
+	if err != nil {
+		print.Warn("Can't read when the profiles were last used:", err)
+	}
+
+	if currUser.Name == "" {
+		currUser, err = user.SelectUser(usersDB, "Switch user", sel)
+	} else {
+		currUser, err = resolveProfile(currUser.Name, sel)
+	}
+
+	if err != nil {
+		print.Error("Can't select user:", err)
+		os.Exit(exitCode(err))
+	}
+
+	g.Entry = currUser
+	result.Profile = newProfileResult(currUser)
+
+	result.Written, err = saveGitconfig(g)
+	if err != nil {
+		print.Error("Can't save edited gitconfig file:", err)
+		os.Exit(exitCode(err))
+	}
+
+	// The use is recorded too when the profile was already set, but not when
+	// the changes were only previewed or declined
+	if !dryRun && !g.Changed() {
+		if err := backups.Used(currUser.Name, base.Names(usersDB)); err != nil {
+			print.Warn("Can't record the use of the profile:", err)
 		}
+	}
 
-		g.Entry = currUser
+	return result
+}
 
-		err = g.Save()
-		if err != nil {
-			print.Error("Can't save edited gitconfig file:", err)
-			os.Exit(1)
-		}
+// saveCurrentProfile stores the profile found in the gitconfig file before
+// it gets overwritten, unless on a dry run
+func saveCurrentProfile(entry base.Entry) {
+	if err := usersDB.Add(entry); err != nil {
+		print.Error("Can't add user to database:", err)
+		os.Exit(exitCode(err))
+	}
+
+	if dryRun {
+		print.Notice("Dry run, the current profile has not been saved:", entry)
+		return
+	}
+
+	if err := usersDB.Save(); err != nil {
+		print.Error("Can't save the current profile:", err)
+		os.Exit(exitCode(err))
+	}
+	print.Success("Current profile saved:", entry)
+}
 
-		print.Success("Selected user:", currUser)
-	},
+// resolveProfile returns the profile designated by the query, prompting
+// the candidates when several profiles match. Without prompts, the error
+// lists the candidates.
+func resolveProfile(query string, sel user.Selection) (base.Entry, error) {
+	entry, err := base.Resolve(usersDB, query)
+
+	var ambiguous *base.AmbiguousError
+	if !errors.As(err, &ambiguous) {
+		return entry, err
+	}
+
+	if !prompt.Interactive() {
+		return base.Entry{}, err
+	}
+
+	if layered, ok := usersDB.(*base.Layered); ok {
+		sel.Origin = layered.Origin
+	}
+	return user.SelectEntry(ambiguous.Matches, fmt.Sprintf("Profiles matching %s", query), sel)
 }
 
 func init() {
 	rootCmd.AddCommand(switchCmd)
 
 	switchCmd.PersistentFlags().StringVarP(&currUser.Name, "name", "n", "", "name of the user to switch to")
+	registerCompletion(switchCmd, "name", completeProfiles)
 	switchCmd.PersistentFlags().BoolVarP(&saveExisting, "save", "w", false, "save the existing git profile before switching")
 	switchCmd.PersistentFlags().BoolVarP(&forceSwitch, "force", "f", false, "force git profile overwrite")
+	addPreviewFlags(switchCmd)
 }
@@ -0,0 +1,220 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/gitsync"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/io/prompt"
+)
+
+// syncResult is the outcome of a sync, Profiles being the number of git
+// profiles of the DB once merged
+type syncResult struct {
+	Action     string `json:"action" yaml:"action"`
+	Repository string `json:"repository" yaml:"repository"`
+	Profiles   int    `json:"profiles" yaml:"profiles"`
+}
+
+func (r syncResult) Rows() print.TableData {
+	return print.TableData{
+		{"Action", "Repository", "Profiles"},
+		{r.Action, r.Repository, fmt.Sprint(r.Profiles)},
+	}
+}
+
+func (r syncResult) Present() {
+	switch r.Action {
+	case "init":
+		print.Success("Profiles sync initialized with", r.Repository)
+	case "pull":
+		print.Success("Git profiles pulled")
+	case "push":
+		print.Success("Git profiles pushed")
+	}
+}
+
+var preferSide string
+
+// syncCmd represents the sync command
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Sync the git profiles DB through a git repository",
+	Long: `Share the git profiles between machines by storing
+them inside a git repository. Profiles changed on
+both sides since the last sync are prompted. An
+encrypted DB can't be synced, the repository holding
+the profiles in plain text.`,
+}
+
+// syncInitCmd represents the sync init command
+var syncInitCmd = &cobra.Command{
+	Use:              "init <repo-url-or-path>",
+	Short:            "Set the git repository to sync the git profiles with",
+	Args:             cobra.ExactArgs(1),
+	PersistentPreRun: preRun(noDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, err := gitsync.Init(conf.Sync, args[0]); err != nil {
+			print.Error("Can't initialize profiles sync:", err)
+			os.Exit(exitCode(err))
+		}
+
+		render(syncResult{Action: "init", Repository: args[0]})
+	},
+}
+
+// syncPullCmd represents the sync pull command
+var syncPullCmd = &cobra.Command{
+	Use:              "pull",
+	Short:            "Merge the git profiles of the repository into the DB",
+	PersistentPreRun: preRun(writeDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		pullProfiles()
+		render(syncResult{Action: "pull", Repository: conf.Sync.Path, Profiles: len(writableDB().List())})
+	},
+}
+
+// syncPushCmd represents the sync push command
+var syncPushCmd = &cobra.Command{
+	Use:              "push",
+	Short:            "Merge and send the git profiles of the DB to the repository",
+	PersistentPreRun: preRun(writeDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		repo := pullProfiles()
+
+		if err := repo.Push(); err != nil {
+			print.Error("Can't push git profiles:", err)
+			os.Exit(exitCode(err))
+		}
+
+		render(syncResult{Action: "push", Repository: conf.Sync.Path, Profiles: len(writableDB().List())})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syncCmd)
+	syncCmd.AddCommand(syncInitCmd)
+	syncCmd.AddCommand(syncPullCmd)
+	syncCmd.AddCommand(syncPushCmd)
+
+	syncCmd.PersistentFlags().StringVar(&preferSide, "prefer", "", "version kept when a profile changed on both sides (local or remote), prompted by default")
+	registerCompletion(syncCmd, "prefer", completeValues(gitsync.LocalSide, gitsync.RemoteSide))
+}
+
+// pullProfiles merges the profiles of the repository with the DB and saves
+// the result to both. The read-only layers of the DB are not synced.
+func pullProfiles() *gitsync.Repository {
+	db := writableDB()
+
+	// The repository would hold the profiles in plain text
+	if b, ok := db.(*base.Base); ok && b.Encrypted() {
+		print.Error("Can't sync an encrypted DB, its profiles would be stored in plain text in the repository")
+		os.Exit(exitUsage)
+	}
+
+	resolve, err := gitsync.Prefer(preferSide, promptConflict)
+	if err != nil {
+		print.Error("Invalid --prefer:", err)
+		os.Exit(exitUsage)
+	}
+
+	repo, err := gitsync.Open(conf.Sync)
+	if err != nil {
+		print.Error("Can't open profiles sync repository:", err)
+		os.Exit(exitCode(err))
+	}
+
+	merged, err := repo.Pull(db.List(), resolve)
+	if err != nil {
+		print.Error("Can't pull git profiles:", err)
+		os.Exit(exitCode(err))
+	}
+
+	if err := replaceProfiles(db, merged); err != nil {
+		print.Error("Can't merge git profiles:", err)
+		os.Exit(exitCode(err))
+	}
+
+	if err := db.Save(); err != nil {
+		print.Error("Can't save merged git profiles:", err)
+		os.Exit(exitCode(err))
+	}
+
+	if err := repo.Saved(); err != nil {
+		print.Error("Can't record the sync:", err)
+		os.Exit(exitCode(err))
+	}
+
+	return repo
+}
+
+// replaceProfiles makes the DB hold exactly the given profiles
+func replaceProfiles(db base.ProfileStore, entries []base.Entry) error {
+	wanted := make(map[string]base.Entry)
+	for _, entry := range entries {
+		wanted[entry.Name] = entry
+	}
+
+	for _, entry := range db.List() {
+		if _, ok := wanted[entry.Name]; !ok {
+			if err := db.Delete(entry.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		existing, err := db.Get(entry.Name)
+		if err != nil {
+			err = db.Add(entry)
+		} else if existing != entry {
+			err = db.Update(existing, entry)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// promptConflict asks which version of a conflicting profile to keep
+func promptConflict(c gitsync.Conflict) (*base.Entry, error) {
+	describe := func(side string, entry *base.Entry) string {
+		if entry == nil {
+			return fmt.Sprintf("Keep %s version: deleted", side)
+		}
+		return fmt.Sprintf("Keep %s version: %s", side, entry.Email)
+	}
+
+	i, err := prompt.Select(fmt.Sprintf("The profile %s changed on both sides", c.Name), prompt.Items(
+		describe(gitsync.LocalSide, c.Local),
+		describe(gitsync.RemoteSide, c.Remote),
+	))
+	if err != nil {
+		return nil, prompt.WithFlag(err, "--prefer")
+	}
+
+	if i == 0 {
+		return c.Local, nil
+	}
+	return c.Remote, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"os"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/io/prompt"
+	"github.com/tabarnhack/git-switch/io/tui"
+)
+
+// uiCmd represents the ui command
+var uiCmd = &cobra.Command{
+	Use:   "ui",
+	Short: "Manage the git profiles in a full-screen terminal app",
+	Long: `Browse the git profiles of the DB, filter them, and
+create, edit, duplicate or delete them in a full-screen
+terminal app. A profile can be applied to the system,
+global or local gitconfig file after previewing the
+changes; the file selected by the flags is proposed first.`,
+	Args:             cobra.NoArgs,
+	PersistentPreRun: preRun(writeDB),
+	Run: func(cmd *cobra.Command, args []string) {
+		if !prompt.Interactive() || !prompt.IsTerminal() {
+			print.Error("The ui needs an interactive terminal, use the other commands instead")
+			os.Exit(exitNoInput)
+		}
+
+		targets, selected := gitconfigTargets()
+
+		// Messages would be drawn over the app, they are only logged
+		print.DisableOutput()
+		err := tui.New(usersDB, backups, targets, selected).Run()
+		print.EnableOutput()
+		if err != nil {
+			print.Error("Can't run the ui:", err)
+			os.Exit(exitCode(err))
+		}
+	},
+}
+
+// gitconfigTargets returns the gitconfig files the profiles can be applied
+// to, and the index of the one selected by the flags
+func gitconfigTargets() ([]tui.Target, int) {
+	targets := []tui.Target{{Name: "system", Path: SYSTEM_GITCONFIG}}
+
+	if home, err := homedir.Dir(); err == nil {
+		targets = append(targets, tui.Target{Name: "global", Path: home + GLOBAL_GITCONFIG})
+	}
+
+	if pwd, err := os.Getwd(); err == nil {
+		if _, err := os.Stat(pwd + LOCAL_GITCONFIG); err == nil {
+			targets = append(targets, tui.Target{Name: "local", Path: pwd + LOCAL_GITCONFIG})
+		}
+	}
+
+	for i, target := range targets {
+		if target.Path == gitconfigFile {
+			return targets, i
+		}
+	}
+
+	return append([]tui.Target{{Name: "selected", Path: gitconfigFile}}, targets...), 0
+}
+
+func init() {
+	rootCmd.AddCommand(uiCmd)
+}
@@ -16,38 +16,104 @@ limitations under the License.
 package cmd
 
 import (
-	"fmt"
 	"os"
+	"sort"
+	"strconv"
 
 	"github.com/spf13/cobra"
+	"github.com/tabarnhack/git-switch/base"
 	"github.com/tabarnhack/git-switch/gitconfig"
 	"github.com/tabarnhack/git-switch/io/print"
 )
 
+// viewResult lists the git profiles of the DB and the active one
+type viewResult struct {
+	Gitconfig string          `json:"gitconfig" yaml:"gitconfig"`
+	Active    *profileResult  `json:"active" yaml:"active"`
+	Profiles  []viewedProfile `json:"profiles" yaml:"profiles"`
+	layered   bool
+}
+
+// viewedProfile is a git profile of the DB. Origin is the database holding
+// it when several are loaded.
+type viewedProfile struct {
+	Name   string `json:"name" yaml:"name"`
+	Email  string `json:"email" yaml:"email"`
+	Origin string `json:"origin,omitempty" yaml:"origin,omitempty"`
+	Active bool   `json:"active" yaml:"active"`
+}
+
+func (r viewResult) Rows() print.TableData {
+	header := []string{"Name", "Email"}
+	if r.layered {
+		header = append(header, "Origin")
+	}
+	header = append(header, "Active")
+
+	data := print.TableData{header}
+	for _, p := range r.Profiles {
+		row := []string{p.Name, p.Email}
+		if r.layered {
+			row = append(row, p.Origin)
+		}
+		data = append(data, append(row, strconv.FormatBool(p.Active)))
+	}
+
+	return data
+}
+
+func (r viewResult) Present() {
+	print.Section("Active git profile")
+	if r.Active != nil {
+		print.Println(r.Active)
+	} else {
+		print.Notice("No git profile is set inside", r.Gitconfig)
+	}
+
+	print.Section("Git profiles list")
+	data := r.Rows()
+	for i := range data {
+		data[i] = data[i][:len(data[i])-1]
+	}
+	print.Table(data)
+}
+
 // viewCmd represents the view command
 var viewCmd = &cobra.Command{
 	Use:   "view",
 	Short: "Dump git profiles DB",
 	Long: `List every git profile stored inside the DB
 and the current git profile set.`,
+	PersistentPreRun: preRun(readDB),
 	Run: func(cmd *cobra.Command, args []string) {
 		g, err := gitconfig.New(gitconfigFile, true)
 		if err != nil {
-			fmt.Println("Can't load gitconfig file:", err)
-			os.Exit(1)
+			print.Error("Can't load gitconfig file:", err)
+			os.Exit(exitCode(err))
 		}
 
-		print.Section("Active git profile")
-		print.Println(g.Entry)
+		layered, isLayered := usersDB.(*base.Layered)
+		result := viewResult{
+			Gitconfig: g.Filename(),
+			Active:    newProfileResult(g.Entry),
+			Profiles:  make([]viewedProfile, 0),
+			layered:   isLayered,
+		}
 
-		print.Section("Git profiles list")
 		entries := usersDB.List()
-		data := print.TableData{[]string{"Name", "Email"}}
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name < entries[j].Name
+		})
+
 		for _, entry := range entries {
-			data = append(data, []string{entry.Name, entry.Email})
+			p := viewedProfile{Name: entry.Name, Email: entry.Email, Active: entry == g.Entry}
+			if isLayered {
+				p.Origin = layered.Origin(entry.Name)
+			}
+			result.Profiles = append(result.Profiles, p)
 		}
 
-		print.Table(data)
+		render(result)
 	},
 }
 
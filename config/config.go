@@ -16,19 +16,51 @@ limitations under the License.
 package config
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Database         DatabaseConfig
-	DefaultGitconfig string
+	Database         DatabaseConfig `json:"database" yaml:"database"`
+	Backup           BackupConfig   `json:"backup" yaml:"backup"`
+	Sync             SyncConfig     `json:"sync" yaml:"sync"`
+	Selector         SelectorConfig `json:"selector" yaml:"selector"`
+	DefaultGitconfig string         `json:"defaultgitconfig" yaml:"defaultgitconfig"`
+
+	// Accessible replaces the interactive prompts and the colors with
+	// plain text, for screen readers and dumb terminals
+	Accessible bool `json:"accessible" yaml:"accessible"`
 }
 
 type DatabaseConfig struct {
-	SearchPaths map[string]string
-	Filename    string
+	// SearchPaths are loaded by precedence, the first one being the
+	// user's database and the others read-only catalogs
+	SearchPaths []string `json:"searchpaths" yaml:"searchpaths"`
+	Filename    string   `json:"filename" yaml:"filename"`
+	Driver      string   `json:"driver" yaml:"driver"`
+	KeyFile     string   `json:"keyfile" yaml:"keyfile"`
 
-	Path string
+	Path string `json:"path" yaml:"path"`
+}
+
+type BackupConfig struct {
+	Path      string `json:"path" yaml:"path"`
+	Retention int    `json:"retention" yaml:"retention"`
+}
+
+type SyncConfig struct {
+	Path string `json:"path" yaml:"path"`
+}
+
+type SelectorConfig struct {
+	// PageSize is the number of profiles listed at once
+	PageSize int `json:"pagesize" yaml:"pagesize"`
 }
 
 type GitconfigConfig struct {
@@ -39,19 +71,188 @@ type GitconfigConfig struct {
 	Path string
 }
 
-func New() (*Config, map[string]string, error) {
+// New returns the default configuration and the directories to search the
+// config file in, following the XDG Base Directory specification.
+func New() (*Config, []string, error) {
 	home, err := homedir.Dir()
 	if err != nil {
 		return nil, nil, err
 	}
-	configPaths["local"] = home + configPaths["local"]
-	defaultSearchPaths["local"] = home + defaultSearchPaths["local"]
+
+	configHome := xdgHome("XDG_CONFIG_HOME", filepath.Join(home, defaultConfigHome))
+	dataHome := xdgHome("XDG_DATA_HOME", filepath.Join(home, defaultDataHome))
+
+	configPaths := []string{filepath.Join(configHome, appName)}
+	for _, dir := range xdgDirs("XDG_CONFIG_DIRS", defaultConfigDirs) {
+		configPaths = append(configPaths, filepath.Join(dir, appName))
+	}
+	configPaths = append(configPaths, legacyConfigPath)
+
+	searchPaths := []string{filepath.Join(dataHome, appName)}
+	for _, dir := range xdgDirs("XDG_DATA_DIRS", defaultDataDirs) {
+		searchPaths = append(searchPaths, filepath.Join(dir, appName))
+	}
 
 	return &Config{
 		Database: DatabaseConfig{
-			SearchPaths: defaultSearchPaths,
+			SearchPaths: searchPaths,
 			Filename:    defaultBaseName,
 		},
+		Backup: BackupConfig{
+			Path:      filepath.Join(dataHome, appName, defaultBackupDir),
+			Retention: defaultBackupRetention,
+		},
+		Sync: SyncConfig{
+			Path: filepath.Join(dataHome, appName, defaultSyncDir),
+		},
+		Selector: SelectorConfig{
+			PageSize: defaultPageSize,
+		},
 		DefaultGitconfig: defaultGitconfig,
 	}, configPaths, nil
 }
+
+// Read loads the configuration without viper, for the commands which must
+// start fast. The given config file, or the first one found in the config
+// paths, is read over the defaults; only YAML and JSON files are supported.
+// Like with viper, the keys are matched case-insensitively and the
+// environment variables override the file. It also returns the files the
+// configuration depends on, found or not.
+func Read(file string) (*Config, []string, error) {
+	c, configPaths, err := New()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	candidates := []string{file}
+	if file == "" {
+		candidates = nil
+		for _, dir := range configPaths {
+			for _, ext := range readExtensions {
+				candidates = append(candidates, filepath.Join(dir, ConfigName+"."+ext))
+			}
+		}
+	}
+
+	f := &File{root: &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}}
+	for i, path := range candidates {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) && file == "" {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// JSON being valid YAML, both are decoded the same way
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, nil, fmt.Errorf("cannot parse %s: %s", path, err)
+		}
+		if len(doc.Content) > 0 && doc.Content[0].Tag != "!!null" {
+			if doc.Content[0].Kind != yaml.MappingNode {
+				return nil, nil, fmt.Errorf("%s must contain a mapping", path)
+			}
+			f.path, f.root = path, &doc
+		}
+		candidates = candidates[:i+1]
+		break
+	}
+
+	lowerKeys(f.root.Content[0])
+	migrateSearchPaths(f.root)
+
+	// viper ignores the empty variables
+	for _, key := range SortedKeys() {
+		if value := os.Getenv(EnvName(key)); value != "" {
+			if err := f.Set(key, value); err != nil {
+				return nil, nil, fmt.Errorf("cannot read %s: %s", EnvName(key), err)
+			}
+		}
+	}
+
+	if err := f.root.Decode(c); err != nil {
+		return nil, nil, fmt.Errorf("cannot decode the configuration: %s", err)
+	}
+
+	return c, candidates, c.Expand()
+}
+
+// lowerKeys lowers the case of the keys of the mapping and its children, the
+// way viper reads them
+func lowerKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			node.Content[i].Value = strings.ToLower(node.Content[i].Value)
+		}
+	}
+
+	for _, child := range node.Content {
+		lowerKeys(child)
+	}
+}
+
+// CacheDir returns the directory of the files git-switch can recompute,
+// following the XDG Base Directory specification
+func CacheDir() (string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(xdgHome("XDG_CACHE_HOME", filepath.Join(home, defaultCacheHome)), appName), nil
+}
+
+// Expand replaces the leading ~ and the environment variables of every path
+func (c *Config) Expand() error {
+	paths := []*string{
+		&c.Database.Path,
+		&c.Database.KeyFile,
+		&c.Backup.Path,
+		&c.Sync.Path,
+		&c.DefaultGitconfig,
+	}
+	for i := range c.Database.SearchPaths {
+		paths = append(paths, &c.Database.SearchPaths[i])
+	}
+
+	for _, path := range paths {
+		expanded, err := ExpandPath(*path)
+		if err != nil {
+			return err
+		}
+		*path = expanded
+	}
+
+	return nil
+}
+
+func ExpandPath(path string) (string, error) {
+	return homedir.Expand(os.ExpandEnv(path))
+}
+
+// xdgHome returns the directory set in the variable, or the default one.
+// The specification requires relative paths to be ignored.
+func xdgHome(env, def string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir
+	}
+
+	return def
+}
+
+// xdgDirs returns the directories listed in the variable, or the default ones
+func xdgDirs(env string, def []string) []string {
+	var dirs []string
+	for _, dir := range strings.Split(os.Getenv(env), string(os.PathListSeparator)) {
+		if filepath.IsAbs(dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+
+	if len(dirs) == 0 {
+		return def
+	}
+
+	return dirs
+}
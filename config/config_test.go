@@ -0,0 +1,125 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// readViper loads the configuration the way the commands do
+func readViper(t *testing.T, file string) *Config {
+	t.Helper()
+
+	c, _, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v := viper.New()
+	v.SetConfigFile(file)
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	for key := range Keys {
+		if err := v.BindEnv(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if paths, ok := LegacySearchPaths(v.Get("database.searchpaths")); ok {
+		v.Set("database.searchpaths", paths)
+	}
+
+	if v.IsSet("database.searchpaths") {
+		c.Database.SearchPaths = nil
+	}
+	if err := v.Unmarshal(c); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Expand(); err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func TestReadLikeViper(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+	}{
+		{"empty", "", nil},
+		{"values", "database:\n  filename: users.db\n  searchpaths: [/home, /usr/share]\nbackup:\n  retention: 3\naccessible: true\n", nil},
+		{"mixed case keys", "Database:\n  FileName: users.db\nSELECTOR:\n  pageSize: 4\nDefaultGitconfig: ~/.gitconfig-work\n", nil},
+		{"json", `{"database": {"path": "/tmp/profiles.db"}, "Sync": {"Path": "/tmp/sync"}}`, nil},
+		{"legacy search paths", "database:\n  searchpaths:\n    global: /usr/share\n    local: /home\n", nil},
+		{"environment", "database:\n  filename: users.db\n", map[string]string{
+			"GIT_SWITCH_DATABASE_FILENAME":    "env.db",
+			"GIT_SWITCH_DATABASE_SEARCHPATHS": "/a,/b",
+			"GIT_SWITCH_BACKUP_RETENTION":     "7",
+			"GIT_SWITCH_ACCESSIBLE":           "true",
+			"GIT_SWITCH_SYNC_PATH":            "~/sync",
+		}},
+		{"environment over mixed case keys", "Backup:\n  Path: /backups\n", map[string]string{
+			"GIT_SWITCH_BACKUP_PATH": "/env/backups",
+		}},
+		{"empty variable", "database:\n  filename: users.db\n", map[string]string{
+			"GIT_SWITCH_DATABASE_FILENAME": "",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range SortedKeys() {
+				name := EnvName(key)
+				previous, ok := os.LookupEnv(name)
+				if value, set := test.env[name]; set {
+					os.Setenv(name, value)
+				} else {
+					os.Unsetenv(name)
+				}
+				t.Cleanup(func() {
+					if ok {
+						os.Setenv(name, previous)
+					} else {
+						os.Unsetenv(name)
+					}
+				})
+			}
+
+			file := filepath.Join(t.TempDir(), "config.yml")
+			if err := os.WriteFile(file, []byte(test.file), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, _, err := Read(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := readViper(t, file); !reflect.DeepEqual(got, want) {
+				t.Errorf("Read() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -18,18 +18,33 @@ package config
 const (
 	ConfigName = "config"
 
+	// EnvPrefix starts the environment variables overriding the config keys
+	EnvPrefix = "GIT_SWITCH"
+
+	appName = "git-switch"
+
 	defaultBaseName  = "profiles.db"
 	defaultGitconfig = "/etc/gitconfig"
+
+	defaultBackupDir       = "backups"
+	defaultBackupRetention = 10
+
+	defaultSyncDir = "sync"
+
+	defaultPageSize = 5
+
+	defaultConfigHome = ".config"
+	defaultDataHome   = ".local/share"
+	defaultCacheHome  = ".cache"
+
+	// legacyConfigPath is searched after the XDG directories
+	legacyConfigPath = "/etc/git-switch"
 )
 
 var (
-	configPaths = map[string]string{
-		"global": "/etc/git-switch/",
-		"local":  "/.config/git-switch",
-	}
-
-	defaultSearchPaths = map[string]string{
-		"local":  "/.local/share/git-switch",
-		"global": "/usr/share/git-switch",
-	}
+	// readExtensions are the config file formats Read supports
+	readExtensions = []string{"json", "yaml", "yml"}
+
+	defaultConfigDirs = []string{"/etc/xdg"}
+	defaultDataDirs   = []string{"/usr/local/share", "/usr/share"}
 )
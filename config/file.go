@@ -0,0 +1,227 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a config file edited in place, keeping its comments
+type File struct {
+	path string
+	root *yaml.Node
+}
+
+// LoadFile reads the config file, an empty one if it does not exist
+func LoadFile(path string) (*File, error) {
+	f := &File{path: path, root: &yaml.Node{Kind: yaml.DocumentNode}}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, f.root); err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %s", path, err)
+		}
+	}
+
+	if len(f.root.Content) == 0 {
+		f.root.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+
+	if f.root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s must contain a mapping", path)
+	}
+
+	return f, nil
+}
+
+func (f *File) Path() string {
+	return f.path
+}
+
+// Has reports whether the key is set in the file
+func (f *File) Has(key string) bool {
+	node := f.root.Content[0]
+	for _, part := range strings.Split(strings.ToLower(key), ".") {
+		if node.Kind != yaml.MappingNode {
+			return false
+		}
+		if node = lookup(node, part); node == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Set writes the value of the key, creating its parents if needed
+func (f *File) Set(key, value string) error {
+	parsed, err := parseValue(key, value)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := node.Encode(parsed); err != nil {
+		return err
+	}
+
+	parts := strings.Split(strings.ToLower(key), ".")
+	mapping := f.root.Content[0]
+	for _, part := range parts[:len(parts)-1] {
+		child := lookup(mapping, part)
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: part}, child)
+		}
+		// A section holding only comments is parsed as a null value
+		if child.Kind == yaml.ScalarNode && child.Tag == "!!null" {
+			*child = yaml.Node{Kind: yaml.MappingNode, HeadComment: child.HeadComment, LineComment: child.LineComment, FootComment: child.FootComment}
+		}
+		if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a mapping in %s", part, f.path)
+		}
+		mapping = child
+	}
+
+	last := parts[len(parts)-1]
+	if existing := lookup(mapping, last); existing != nil {
+		// Keep the comments attached to the previous value
+		node.HeadComment, node.LineComment, node.FootComment = existing.HeadComment, existing.LineComment, existing.FootComment
+		*existing = node
+		return nil
+	}
+
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: last}, &node)
+	return nil
+}
+
+// Unset removes the key, it returns false if the key was not set. Unknown
+// keys can be removed as long as they are set.
+func (f *File) Unset(key string) (bool, error) {
+	if _, ok := Keys[strings.ToLower(key)]; !ok && !f.Has(key) {
+		return false, fmt.Errorf("unknown config key %s", key)
+	}
+
+	parts := strings.Split(strings.ToLower(key), ".")
+	mapping := f.root.Content[0]
+	for _, part := range parts[:len(parts)-1] {
+		mapping = lookup(mapping, part)
+		if mapping == nil || mapping.Kind != yaml.MappingNode {
+			return false, nil
+		}
+	}
+
+	last := parts[len(parts)-1]
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, last) {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (f *File) Save() error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f.root); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(f.path, buf.Bytes(), 0600)
+}
+
+// lookup returns the value of the key in the mapping, keys being matched
+// case-insensitively like viper does
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if strings.EqualFold(mapping.Content[i].Value, key) {
+			return mapping.Content[i+1]
+		}
+	}
+
+	return nil
+}
+
+// Template returns a commented config file holding the default values
+func (c *Config) Template() []byte {
+	var searchPaths strings.Builder
+	for _, path := range c.Database.SearchPaths {
+		fmt.Fprintf(&searchPaths, "  #   - %s\n", path)
+	}
+
+	return []byte(fmt.Sprintf(`# git-switch configuration
+#
+# Paths may start with ~ and contain environment variables. The commented
+# values are the defaults.
+
+database:
+  # Name of the database file looked up in the search paths
+  filename: %s
+  # Directories searched for the database by precedence. The first one holds
+  # the user's database, the following ones read-only catalogs.
+  # searchpaths:
+%s  # Backend of the database: bolt, yaml or toml. It is guessed from
+  # the file extension by default.
+  # driver: bolt
+  # File containing the passphrase of an encrypted database
+  # keyfile: ~/.config/git-switch/key
+  # Database file to use instead of searching the search paths
+  # path: ~/profiles.db
+
+backup:
+  # Directory where gitconfig files are backed up before being written
+  # path: %s
+  # Number of backups kept for each gitconfig file, 0 keeps every backup
+  retention: %d
+
+sync:
+  # Local clone of the repository the profiles are synced through
+  # path: %s
+
+selector:
+  # Number of profiles listed at once when prompting for one
+  pagesize: %d
+
+# gitconfig file used when none is selected by the flags
+defaultgitconfig: %s
+
+# Prompt with numbered menus and plain text instead of interactive widgets
+# and colors, for screen readers and dumb terminals. It is enabled when TERM
+# is dumb.
+# accessible: false
+`, c.Database.Filename, searchPaths.String(), c.Backup.Path, c.Backup.Retention, c.Sync.Path, c.Selector.PageSize, c.DefaultGitconfig))
+}
@@ -0,0 +1,131 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kind is the type of the value of a configuration key
+type Kind int
+
+const (
+	String Kind = iota
+	Int
+	Bool
+	List
+)
+
+// Keys lists every configuration key with the type of its value
+var Keys = map[string]Kind{
+	"database.searchpaths": List,
+	"database.filename":    String,
+	"database.driver":      String,
+	"database.keyfile":     String,
+	"database.path":        String,
+	"backup.path":          String,
+	"backup.retention":     Int,
+	"sync.path":            String,
+	"selector.pagesize":    Int,
+	"defaultgitconfig":     String,
+	"accessible":           Bool,
+}
+
+// SortedKeys returns the configuration keys in alphabetical order
+func SortedKeys() []string {
+	keys := make([]string, 0, len(Keys))
+	for key := range Keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Get returns the value of a configuration key
+func (c *Config) Get(key string) (interface{}, error) {
+	values := map[string]interface{}{
+		"database.searchpaths": c.Database.SearchPaths,
+		"database.filename":    c.Database.Filename,
+		"database.driver":      c.Database.Driver,
+		"database.keyfile":     c.Database.KeyFile,
+		"database.path":        c.Database.Path,
+		"backup.path":          c.Backup.Path,
+		"backup.retention":     c.Backup.Retention,
+		"sync.path":            c.Sync.Path,
+		"selector.pagesize":    c.Selector.PageSize,
+		"defaultgitconfig":     c.DefaultGitconfig,
+		"accessible":           c.Accessible,
+	}
+
+	value, ok := values[strings.ToLower(key)]
+	if !ok {
+		return nil, fmt.Errorf("unknown config key %s", key)
+	}
+
+	return value, nil
+}
+
+// Format returns a configuration value as written on the command line
+func Format(value interface{}) string {
+	if list, ok := value.([]string); ok {
+		return strings.Join(list, ",")
+	}
+
+	return fmt.Sprint(value)
+}
+
+// parseValue converts a command line value to the type of the key, lists
+// being comma separated
+func parseValue(key, value string) (interface{}, error) {
+	kind, ok := Keys[strings.ToLower(key)]
+	if !ok {
+		return nil, fmt.Errorf("unknown config key %s", key)
+	}
+
+	switch kind {
+	case Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects an integer, got %q", key, value)
+		}
+		return n, nil
+	case Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s expects true or false, got %q", key, value)
+		}
+		return b, nil
+	case List:
+		var list []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		return list, nil
+	}
+
+	return value, nil
+}
+
+// EnvName returns the environment variable overriding a configuration key
+func EnvName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
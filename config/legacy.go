@@ -0,0 +1,95 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Layer names of the former map form of the search paths
+const (
+	localLayer  = "local"
+	globalLayer = "global"
+)
+
+// LegacySearchPaths returns the search paths given in their former form, a
+// map of directories by layer, as a list ordered by precedence: the user's
+// local layer, the global one, then the others by name. It reports false
+// when the value is not such a map.
+func LegacySearchPaths(value interface{}) ([]string, bool) {
+	layers, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	dirs := make(map[string]string, len(layers))
+	var names []string
+	for name, dir := range layers {
+		s, ok := dir.(string)
+		if !ok {
+			return nil, false
+		}
+
+		name = strings.ToLower(name)
+		dirs[name] = s
+		if name != localLayer && name != globalLayer {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var paths []string
+	for _, name := range append([]string{localLayer, globalLayer}, names...) {
+		if dir, ok := dirs[name]; ok {
+			paths = append(paths, dir)
+		}
+	}
+
+	return paths, true
+}
+
+// migrateSearchPaths replaces the former map form of the search paths in the
+// document by the list, reporting whether it was found
+func migrateSearchPaths(doc *yaml.Node) bool {
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return false
+	}
+
+	database := lookup(doc.Content[0], "database")
+	if database == nil || database.Kind != yaml.MappingNode {
+		return false
+	}
+
+	node := lookup(database, "searchpaths")
+	if node == nil || node.Kind != yaml.MappingNode {
+		return false
+	}
+
+	var layers map[string]interface{}
+	if node.Decode(&layers) != nil {
+		return false
+	}
+
+	paths, ok := LegacySearchPaths(layers)
+	if !ok {
+		return false
+	}
+
+	return node.Encode(paths) == nil
+}
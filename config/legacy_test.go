@@ -0,0 +1,81 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLegacySearchPaths(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  []string
+		ok    bool
+	}{
+		{"list", []interface{}{"/a"}, nil, false},
+		{"local and global", map[string]interface{}{"global": "/usr/share", "local": "/home"}, []string{"/home", "/usr/share"}, true},
+		{"other layers", map[string]interface{}{"b": "/b", "Local": "/home", "a": "/a"}, []string{"/home", "/a", "/b"}, true},
+		{"not a directory", map[string]interface{}{"local": []interface{}{"/home"}}, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := LegacySearchPaths(test.value)
+			if ok != test.ok || !reflect.DeepEqual(got, test.want) {
+				t.Errorf("LegacySearchPaths() = %v, %v, want %v, %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
+
+func TestMigrateSearchPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		want     []string
+		migrated bool
+	}{
+		{"map", "database:\n  searchpaths:\n    global: /usr/share\n    local: /home\n", []string{"/home", "/usr/share"}, true},
+		{"json map", `{"database": {"searchpaths": {"local": "/home"}}}`, []string{"/home"}, true},
+		{"list", "database:\n  searchpaths: [/home, /usr/share]\n", []string{"/home", "/usr/share"}, false},
+		{"no search paths", "database:\n  filename: profiles.db\n", nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(test.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			if migrated := migrateSearchPaths(&doc); migrated != test.migrated {
+				t.Errorf("migrateSearchPaths() = %v, want %v", migrated, test.migrated)
+			}
+
+			var c Config
+			if err := doc.Decode(&c); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(c.Database.SearchPaths, test.want) {
+				t.Errorf("SearchPaths = %v, want %v", c.Database.SearchPaths, test.want)
+			}
+		})
+	}
+}
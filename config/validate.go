@@ -0,0 +1,284 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Problem is an invalid configuration key with the reason it is rejected
+type Problem struct {
+	Key     string
+	Message string
+}
+
+// CheckSettings reports the unknown keys and the values of the wrong type
+// among the settings read from the config file and the environment
+func CheckSettings(settings map[string]interface{}) []Problem {
+	var problems []Problem
+	checkSettings(&problems, "", settings)
+
+	sort.Slice(problems, func(i, j int) bool {
+		return problems[i].Key < problems[j].Key
+	})
+	return problems
+}
+
+func checkSettings(problems *[]Problem, prefix string, settings map[string]interface{}) {
+	for name, value := range settings {
+		key := prefix + strings.ToLower(name)
+
+		if isSection(key) {
+			switch nested := value.(type) {
+			case map[string]interface{}:
+				checkSettings(problems, key+".", nested)
+			case nil:
+				// A section holding only comments
+			default:
+				*problems = append(*problems, Problem{key, fmt.Sprintf("%s is a section, set one of its keys instead: %s", key, strings.Join(sectionKeys(key), ", "))})
+			}
+			continue
+		}
+
+		kind, ok := Keys[key]
+		if !ok {
+			message := "unknown key"
+			if suggestion := suggestKey(key); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %s?", suggestion)
+			}
+			*problems = append(*problems, Problem{key, message})
+			continue
+		}
+
+		if message := checkKind(kind, value); message != "" {
+			*problems = append(*problems, Problem{key, message})
+		}
+	}
+}
+
+// checkKind returns why the value can't be decoded as the kind, if it can't
+func checkKind(kind Kind, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	switch kind {
+	case Int:
+		switch v := value.(type) {
+		case int, int64, int32, uint, uint64, uint32:
+			return ""
+		case string:
+			if _, err := strconv.Atoi(v); err == nil {
+				return ""
+			}
+		}
+		return fmt.Sprintf("expects an integer, got %v", value)
+	case Bool:
+		switch v := value.(type) {
+		case bool:
+			return ""
+		case string:
+			if _, err := strconv.ParseBool(v); err == nil {
+				return ""
+			}
+		}
+		return fmt.Sprintf("expects true or false, got %v", value)
+	case List:
+		switch v := value.(type) {
+		case string, []string:
+			return ""
+		case []interface{}:
+			for _, item := range v {
+				if !isScalar(item) {
+					return fmt.Sprintf("expects a list of strings, got the item %v", item)
+				}
+			}
+			return ""
+		}
+		return fmt.Sprintf("expects a list of strings, got %v", value)
+	}
+
+	if !isScalar(value) {
+		return fmt.Sprintf("expects a string, got %v", value)
+	}
+	return ""
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case map[string]interface{}, map[interface{}]interface{}, []interface{}, []string:
+		return false
+	}
+
+	return true
+}
+
+// isSection reports whether the key holds other keys
+func isSection(key string) bool {
+	return len(sectionKeys(key)) > 0
+}
+
+func sectionKeys(section string) []string {
+	var keys []string
+	for _, key := range SortedKeys() {
+		if strings.HasPrefix(key, section+".") {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// suggestKey returns the known key closest to a mistyped one, if any is close
+// enough. A key set in the wrong section is matched by its last part.
+func suggestKey(key string) string {
+	last := key[strings.LastIndex(key, ".")+1:]
+
+	best, bestDistance := "", 3
+	for _, known := range SortedKeys() {
+		if strings.HasSuffix(known, "."+last) {
+			return known
+		}
+
+		if d := distance(key, known); d < bestDistance {
+			best, bestDistance = known, d
+		}
+	}
+
+	return best
+}
+
+// distance returns the Levenshtein distance between two strings
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
+
+// Validate reports the values of the expanded configuration that can't work,
+// like a directory given where a file is expected
+func (c *Config) Validate() []Problem {
+	var problems []Problem
+	add := func(key, format string, args ...interface{}) {
+		problems = append(problems, Problem{key, fmt.Sprintf(format, args...)})
+	}
+
+	switch {
+	case c.Database.Filename == "":
+		add("database.filename", "the database file name can't be empty")
+	case filepath.Base(c.Database.Filename) != c.Database.Filename:
+		add("database.filename", "expects a file name without directory, got %s, use database.path to set the database file", c.Database.Filename)
+	}
+
+	if len(c.Database.SearchPaths) == 0 && c.Database.Path == "" {
+		add("database.searchpaths", "at least one search path is needed unless database.path is set")
+	}
+	for _, dir := range c.Database.SearchPaths {
+		if msg := checkDir(dir); msg != "" {
+			add("database.searchpaths", msg)
+		}
+	}
+
+	if c.Database.Path != "" {
+		if info, err := os.Stat(c.Database.Path); err == nil && info.IsDir() {
+			add("database.path", "%s is a directory, expected the database file", c.Database.Path)
+		}
+	}
+
+	// The memory store of the tests is not a driver, it would lose every change
+	switch strings.ToLower(c.Database.Driver) {
+	case "", "bolt", "yaml", "toml":
+	default:
+		add("database.driver", "unknown driver %s, expected bolt, yaml or toml", c.Database.Driver)
+	}
+
+	if c.Database.KeyFile != "" {
+		if info, err := os.Stat(c.Database.KeyFile); err != nil {
+			add("database.keyfile", "can't read the key file: %s", err)
+		} else if info.IsDir() {
+			add("database.keyfile", "%s is a directory, expected the file holding the passphrase", c.Database.KeyFile)
+		}
+	}
+
+	if msg := checkDir(c.Backup.Path); msg != "" {
+		add("backup.path", msg)
+	}
+	if c.Backup.Retention < 0 {
+		add("backup.retention", "expects 0 to keep every backup or a positive number, got %d", c.Backup.Retention)
+	}
+
+	if msg := checkDir(c.Sync.Path); msg != "" {
+		add("sync.path", msg)
+	}
+
+	if c.Selector.PageSize < 1 {
+		add("selector.pagesize", "expects a positive number of profiles, got %d", c.Selector.PageSize)
+	}
+
+	if c.DefaultGitconfig == "" {
+		add("defaultgitconfig", "the default gitconfig file can't be empty")
+	} else if info, err := os.Stat(c.DefaultGitconfig); err == nil && info.IsDir() {
+		add("defaultgitconfig", "%s is a directory, expected a gitconfig file", c.DefaultGitconfig)
+	}
+
+	return problems
+}
+
+// checkDir returns why the path can't be used as a directory, if it can't.
+// The directory doesn't have to exist yet.
+func checkDir(path string) string {
+	if !filepath.IsAbs(path) {
+		return fmt.Sprintf("expects an absolute directory, got %q", path)
+	}
+
+	if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		return fmt.Sprintf("%s is a file, expected a directory", path)
+	}
+
+	return ""
+}
@@ -0,0 +1,48 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import "testing"
+
+func TestValidateDriver(t *testing.T) {
+	tests := []struct {
+		driver string
+		valid  bool
+	}{
+		{"", true},
+		{"bolt", true},
+		{"YAML", true},
+		{"toml", true},
+		{"memory", false},
+		{"sqlite", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.driver, func(t *testing.T) {
+			c := Config{Database: DatabaseConfig{Driver: test.driver}}
+
+			valid := true
+			for _, problem := range c.Validate() {
+				if problem.Key == "database.driver" {
+					valid = false
+				}
+			}
+			if valid != test.valid {
+				t.Errorf("Validate() accepts the driver %q: %v, want %v", test.driver, valid, test.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gitconfig
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/ini.v1"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+// Scopes of the gitconfig files, from the least to the most specific
+const (
+	SystemScope = "system"
+	GlobalScope = "global"
+	LocalScope  = "local"
+)
+
+// Effective is the git profile git commits with inside a repository
+type Effective struct {
+	base.Entry
+
+	// Scope is the scope of the most specific file setting the profile
+	Scope string
+	// Files are every config file read, included ones and missing ones too
+	Files []string
+}
+
+// source is a gitconfig file read with the scope it belongs to
+type source struct {
+	scope string
+	path  string
+}
+
+// FindGitDir returns the git directory of the repository containing dir,
+// false outside any repository
+func FindGitDir(dir string) (string, bool) {
+	for {
+		path := filepath.Join(dir, ".git")
+		if info, err := os.Stat(path); err == nil {
+			if info.IsDir() {
+				return path, true
+			}
+			// Worktrees and submodules point to their git directory
+			if gitDir, ok := readGitFile(path); ok {
+				return gitDir, true
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+func readGitFile(path string) (string, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", false
+	}
+
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+
+	return gitDir, true
+}
+
+// ResolveEffective reads the gitconfig files like git does inside the
+// repository of the git directory: the system, global and repository files
+// by increasing precedence, following their includes. The gitdir, gitdir/i
+// and onbranch conditions of the conditional includes are evaluated, the
+// others never match.
+func ResolveEffective(gitDir string) (Effective, error) {
+	var effective Effective
+	visited := make(map[string]bool)
+
+	var read func(src source) error
+	read = func(src source) error {
+		path, err := filepath.Abs(src.path)
+		if err != nil {
+			return err
+		}
+
+		effective.Files = append(effective.Files, path)
+		if visited[path] {
+			return nil
+		}
+		visited[path] = true
+
+		cfg, err := ini.LoadSources(ini.LoadOptions{AllowShadows: true, InsensitiveKeys: true}, path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Sections are read in order so that the later values win like in git
+		for _, section := range cfg.Sections() {
+			name := section.Name()
+			switch lower := strings.ToLower(name); {
+			case lower == userSection:
+				if value, ok := lastValue(section, nameKey); ok {
+					effective.Name, effective.Scope = value, src.scope
+				}
+				if value, ok := lastValue(section, emailKey); ok {
+					effective.Email, effective.Scope = value, src.scope
+				}
+				continue
+			case lower == includeSection:
+			case strings.HasPrefix(lower, includeIfSection+" "):
+				condition := strings.Trim(strings.TrimSpace(name[len(includeIfSection)+1:]), `"`)
+				if !matchCondition(condition, gitDir, path) {
+					continue
+				}
+			default:
+				continue
+			}
+
+			for _, include := range section.Key(pathKey).ValueWithShadows() {
+				if include == "" {
+					continue
+				}
+
+				include, err := homedir.Expand(include)
+				if err != nil {
+					return err
+				}
+				if !filepath.IsAbs(include) {
+					include = filepath.Join(filepath.Dir(path), include)
+				}
+
+				if err := read(source{scope: src.scope, path: include}); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	for _, src := range sources(gitDir) {
+		if err := read(src); err != nil {
+			return Effective{}, err
+		}
+	}
+
+	return effective, nil
+}
+
+// lastValue returns the value of the key set last in the section
+func lastValue(section *ini.Section, key string) (string, bool) {
+	if !section.HasKey(key) {
+		return "", false
+	}
+
+	values := section.Key(key).ValueWithShadows()
+	return values[len(values)-1], true
+}
+
+// sources returns the gitconfig files git reads inside the repository, from
+// the least to the most specific one
+func sources(gitDir string) []source {
+	var srcs []source
+
+	if os.Getenv("GIT_CONFIG_NOSYSTEM") == "" {
+		system := os.Getenv("GIT_CONFIG_SYSTEM")
+		if system == "" {
+			system = "/etc/gitconfig"
+		}
+		srcs = append(srcs, source{SystemScope, system})
+	}
+
+	if global := os.Getenv("GIT_CONFIG_GLOBAL"); global != "" {
+		srcs = append(srcs, source{GlobalScope, global})
+	} else if home, err := homedir.Dir(); err == nil {
+		xdg := os.Getenv("XDG_CONFIG_HOME")
+		if xdg == "" {
+			xdg = filepath.Join(home, ".config")
+		}
+		srcs = append(srcs,
+			source{GlobalScope, filepath.Join(xdg, "git", "config")},
+			source{GlobalScope, filepath.Join(home, ".gitconfig")},
+		)
+	}
+
+	// The worktrees share the config of the main repository
+	common := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+	}
+
+	return append(srcs, source{LocalScope, filepath.Join(common, "config")})
+}
+
+// matchCondition evaluates the condition of a conditional include found in
+// the config file
+func matchCondition(condition, gitDir, configFile string) bool {
+	switch {
+	case strings.HasPrefix(condition, "onbranch:"):
+		return matchBranch(strings.TrimPrefix(condition, "onbranch:"), gitDir)
+	default:
+		return matchGitDir(condition, gitDir, configFile)
+	}
+}
+
+// matchBranch evaluates the pattern of an onbranch condition against the
+// branch checked out in the git directory
+func matchBranch(pattern, gitDir string) bool {
+	data, err := os.ReadFile(filepath.Join(gitDir, "HEAD"))
+	if err != nil {
+		return false
+	}
+
+	// A detached HEAD is on no branch
+	head := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(head, "ref: refs/heads/") {
+		return false
+	}
+	branch := strings.TrimPrefix(head, "ref: refs/heads/")
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+
+	re, err := globRegexp(pattern, false)
+	return err == nil && re.MatchString(branch)
+}
+
+// matchGitDir evaluates the gitdir and gitdir/i conditions of a conditional
+// include found in the config file
+func matchGitDir(condition, gitDir, configFile string) bool {
+	var pattern string
+	var insensitive bool
+	switch {
+	case strings.HasPrefix(condition, "gitdir:"):
+		pattern = strings.TrimPrefix(condition, "gitdir:")
+	case strings.HasPrefix(condition, "gitdir/i:"):
+		pattern, insensitive = strings.TrimPrefix(condition, "gitdir/i:"), true
+	default:
+		return false
+	}
+
+	// A trailing slash matches every directory inside, it is lost when the
+	// pattern is joined to a directory
+	prefix := strings.HasSuffix(pattern, "/")
+
+	if strings.HasPrefix(pattern, "~/") {
+		expanded, err := homedir.Expand(pattern)
+		if err != nil {
+			return false
+		}
+		pattern = expanded
+	} else if strings.HasPrefix(pattern, "./") {
+		pattern = filepath.Join(filepath.Dir(configFile), pattern[2:])
+	}
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = "**/" + pattern
+	}
+	if prefix {
+		pattern = strings.TrimSuffix(pattern, "/") + "/**"
+	}
+
+	re, err := globRegexp(pattern, insensitive)
+	if err != nil {
+		return false
+	}
+
+	if re.MatchString(gitDir) {
+		return true
+	}
+	// git matches the path with the symbolic links resolved too
+	resolved, err := filepath.EvalSymlinks(gitDir)
+	return err == nil && re.MatchString(resolved)
+}
+
+// globRegexp converts a wildmatch pattern, where ** matches across the
+// directories, to a regular expression
+func globRegexp(pattern string, insensitive bool) (*regexp.Regexp, error) {
+	var b strings.Builder
+	if insensitive {
+		b.WriteString("(?i)")
+	}
+	b.WriteString("^")
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,225 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gitconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+// setenv sets the environment variables for the test, unsetting the empty
+// ones, and restores them afterwards
+func setenv(t *testing.T, env map[string]string) {
+	t.Helper()
+
+	homedir.DisableCache = true
+	for name, value := range env {
+		previous, ok := os.LookupEnv(name)
+		if value == "" {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, value)
+		}
+
+		name := name
+		t.Cleanup(func() {
+			if ok {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+	}
+}
+
+// writeFiles writes the files by path relative to dir
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGlobRegexp(t *testing.T) {
+	tests := []struct {
+		pattern     string
+		insensitive bool
+		path        string
+		want        bool
+	}{
+		{"/home/jane/work/**", false, "/home/jane/work/project/.git", true},
+		{"/home/jane/work/**", false, "/home/jane/work", false},
+		{"/home/jane/work/**", false, "/home/jane/workshop/.git", false},
+		{"**/work/.git", false, "/home/jane/work/.git", true},
+		{"**/work/.git", false, "/work/.git", true},
+		{"**/work/.git", false, "work/.git", true},
+		{"**/work/.git", false, "/home/jane/homework/.git", false},
+		{"/src/*/.git", false, "/src/project/.git", true},
+		{"/src/*/.git", false, "/src/group/project/.git", false},
+		{"/src/?/.git", false, "/src/a/.git", true},
+		{"/src/?/.git", false, "/src/ab/.git", false},
+		{"/src/a.b/.git", false, "/src/axb/.git", false},
+		{"/Src/Project/.git", false, "/src/project/.git", false},
+		{"/Src/Project/.git", true, "/src/project/.git", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.pattern+" "+test.path, func(t *testing.T) {
+			re, err := globRegexp(test.pattern, test.insensitive)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := re.MatchString(test.path); got != test.want {
+				t.Errorf("globRegexp(%q, %v) matches %q = %v, want %v", test.pattern, test.insensitive, test.path, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMatchCondition(t *testing.T) {
+	home := t.TempDir()
+	setenv(t, map[string]string{"HOME": home})
+
+	gitDir := filepath.Join(home, "work", "project", ".git")
+	writeFiles(t, gitDir, map[string]string{"HEAD": "ref: refs/heads/feature/login\n"})
+	configFile := filepath.Join(home, ".gitconfig")
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		// A trailing slash matches every repository inside the directory
+		{"gitdir:~/work/", true},
+		{"gitdir:~/work", false},
+		{"gitdir:~/work/project/.git", true},
+		{"gitdir:~/work/project", false},
+		{"gitdir:" + home + "/work/", true},
+		{"gitdir:~/other/", false},
+		// The relative patterns match at any depth
+		{"gitdir:project/.git", true},
+		{"gitdir:work/", true},
+		// Except the ones relative to the directory of the config file
+		{"gitdir:./work/", true},
+		{"gitdir:./project/", false},
+		{"gitdir:~/WORK/", false},
+		{"gitdir/i:~/WORK/", true},
+		{"onbranch:feature/login", true},
+		{"onbranch:feature/", true},
+		{"onbranch:feature/*", true},
+		{"onbranch:feat", false},
+		{"onbranch:main", false},
+		{"hasconfig:remote.*.url:https://example.com/**", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.condition, func(t *testing.T) {
+			if got := matchCondition(test.condition, gitDir, configFile); got != test.want {
+				t.Errorf("matchCondition(%q) = %v, want %v", test.condition, got, test.want)
+			}
+		})
+	}
+}
+
+func TestMatchBranch(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    string
+		pattern string
+		want    bool
+	}{
+		{"branch", "ref: refs/heads/main\n", "main", true},
+		{"other branch", "ref: refs/heads/main\n", "master", false},
+		{"nested branch", "ref: refs/heads/oss/lib\n", "oss/", true},
+		{"deeply nested branch", "ref: refs/heads/oss/lib/fix\n", "oss/*", false},
+		{"wildcard", "ref: refs/heads/oss/lib/fix\n", "oss/**", true},
+		{"detached", "0123456789abcdef0123456789abcdef01234567\n", "main", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gitDir := t.TempDir()
+			writeFiles(t, gitDir, map[string]string{"HEAD": test.head})
+
+			if got := matchBranch(test.pattern, gitDir); got != test.want {
+				t.Errorf("matchBranch(%q) = %v, want %v", test.pattern, got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveEffective(t *testing.T) {
+	home := t.TempDir()
+	setenv(t, map[string]string{
+		"HOME":                home,
+		"GIT_CONFIG_NOSYSTEM": "1",
+		"GIT_CONFIG_GLOBAL":   "",
+		"XDG_CONFIG_HOME":     "",
+	})
+
+	writeFiles(t, home, map[string]string{
+		".gitconfig": `[user]
+	Name = Jane
+	EMAIL = jane@home.org
+[includeIf "gitdir:~/work/"]
+	path = .gitconfig-work
+[includeIf "onbranch:oss/"]
+	path = .gitconfig-oss
+`,
+		".gitconfig-work":       "[user]\n\temail = jane@corp.com\n",
+		".gitconfig-oss":        "[User]\n\tname = Jane OSS\n",
+		"work/app/.git/HEAD":    "ref: refs/heads/main\n",
+		"src/lib/.git/HEAD":     "ref: refs/heads/oss/lib\n",
+		"src/site/.git/HEAD":    "ref: refs/heads/main\n",
+		"src/site/.git/config":  "[user]\n\tName = Site\n",
+		"src/other/.git/HEAD":   "ref: refs/heads/main\n",
+		"src/other/.git/config": "[core]\n\tbare = false\n",
+	})
+
+	tests := []struct {
+		repo  string
+		want  base.Entry
+		scope string
+	}{
+		{"work/app", base.Entry{Name: "Jane", Email: "jane@corp.com"}, GlobalScope},
+		{"src/lib", base.Entry{Name: "Jane OSS", Email: "jane@home.org"}, GlobalScope},
+		{"src/site", base.Entry{Name: "Site", Email: "jane@home.org"}, LocalScope},
+		{"src/other", base.Entry{Name: "Jane", Email: "jane@home.org"}, GlobalScope},
+	}
+
+	for _, test := range tests {
+		t.Run(test.repo, func(t *testing.T) {
+			effective, err := ResolveEffective(filepath.Join(home, test.repo, ".git"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if effective.Entry != test.want || effective.Scope != test.scope {
+				t.Errorf("ResolveEffective() = %v in %s, want %v in %s", effective.Entry, effective.Scope, test.want, test.scope)
+			}
+		})
+	}
+}
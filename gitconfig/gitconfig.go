@@ -16,9 +16,14 @@ limitations under the License.
 package gitconfig
 
 import (
+	"bytes"
+	"os"
+
 	"gopkg.in/ini.v1"
 
 	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/io/print"
+	"github.com/tabarnhack/git-switch/journal"
 )
 
 const (
@@ -32,8 +37,12 @@ type Gitconfig struct {
 	filename    string
 	cfg         *ini.File
 	userSection *ini.Section
+	loaded      base.Entry
 
 	Entry base.Entry
+
+	// Journal, when set, keeps a backup of the file before every write
+	Journal *journal.Journal
 }
 
 func New(filename string, readOnly bool) (*Gitconfig, error) {
@@ -43,28 +52,60 @@ func New(filename string, readOnly bool) (*Gitconfig, error) {
 	}
 
 	s := cfg.Section(userSection)
+	entry := base.Entry{Name: s.Key(nameKey).String(), Email: s.Key(emailKey).String()}
 
 	return &Gitconfig{
 		filename:    filename,
 		cfg:         cfg,
 		userSection: s,
-		Entry:       base.Entry{Name: s.Key(nameKey).String(), Email: s.Key(emailKey).String()},
+		loaded:      entry,
+		Entry:       entry,
 	}, nil
 }
 
-func (g *Gitconfig) Save() error {
-	prev := base.Entry{
-		Name:  g.userSection.Key(nameKey).String(),
-		Email: g.userSection.Key(emailKey).String(),
-	}
+func (g *Gitconfig) Filename() string {
+	return g.filename
+}
 
+// Changed reports whether Entry differs from the profile the file was loaded with.
+func (g *Gitconfig) Changed() bool {
+	return g.Entry != g.loaded
+}
+
+// Render returns the content Save would write to the file.
+func (g *Gitconfig) Render() ([]byte, error) {
+	g.userSection.Key(nameKey).SetValue(g.Entry.Name)
+	g.userSection.Key(emailKey).SetValue(g.Entry.Email)
+
+	var buf bytes.Buffer
+	_, err := g.cfg.WriteToIndent(&buf, "\t")
+	return buf.Bytes(), err
+}
+
+func (g *Gitconfig) Save() error {
 	// Not needed to write to file if we have the same profile
-	if g.Entry == prev {
+	if !g.Changed() {
 		return nil
 	}
 
-	g.userSection.Key(nameKey).SetValue(g.Entry.Name)
-	g.userSection.Key(emailKey).SetValue(g.Entry.Email)
+	if g.Journal != nil {
+		r, err := g.Journal.Snapshot(g.filename)
+		if err != nil {
+			return err
+		}
+		print.Debug("Backed up", g.filename, "as backup", r.ID)
+	}
+
+	content, err := g.Render()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(g.filename, content, 0666); err != nil {
+		return err
+	}
+	g.loaded = g.Entry
+	print.Debug("Wrote the profile", g.Entry, "to", g.filename)
 
-	return g.cfg.SaveToIndent(g.filename, "\t")
+	return nil
 }
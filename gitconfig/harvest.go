@@ -0,0 +1,172 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gitconfig
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/ini.v1"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+const (
+	includeSection   = "include"
+	includeIfSection = "includeif"
+	pathKey          = "path"
+)
+
+// Identity is a git profile found in a gitconfig file
+type Identity struct {
+	base.Entry
+
+	Filename string
+}
+
+// DefaultPaths returns the gitconfig files git reads outside a repository
+func DefaultPaths() []string {
+	paths := []string{"/etc/gitconfig"}
+
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if home, err := homedir.Dir(); err == nil {
+		if xdg == "" {
+			xdg = filepath.Join(home, ".config")
+		}
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+	if xdg != "" {
+		paths = append(paths, filepath.Join(xdg, "git", "config"))
+	}
+
+	return paths
+}
+
+// Scan returns the config file of every git repository found inside dir
+func Scan(dir string) ([]string, error) {
+	var paths []string
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped rather than aborting the scan
+			if d != nil && d.IsDir() && path != dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		if d.IsDir() && d.Name() == ".git" {
+			config := filepath.Join(path, "config")
+			if _, err := os.Stat(config); err == nil {
+				paths = append(paths, config)
+			}
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+
+	return paths, err
+}
+
+// Harvest collects every distinct git profile set inside the gitconfig files,
+// following their includes. Missing files are ignored like git does.
+func Harvest(paths []string) ([]Identity, error) {
+	var identities []Identity
+	seen := make(map[base.Entry]bool)
+	visited := make(map[string]bool)
+
+	var harvest func(path string) error
+	harvest = func(path string) error {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+
+		if visited[path] {
+			return nil
+		}
+		visited[path] = true
+
+		cfg, err := ini.LoadSources(ini.LoadOptions{AllowShadows: true, InsensitiveSections: true, InsensitiveKeys: true}, path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		s := cfg.Section(userSection)
+		entry := base.Entry{Name: s.Key(nameKey).String(), Email: s.Key(emailKey).String()}
+		if !entry.IsIncomplete() && !seen[entry] {
+			seen[entry] = true
+			identities = append(identities, Identity{Entry: entry, Filename: path})
+		}
+
+		for _, section := range cfg.Sections() {
+			name := section.Name()
+			if name != includeSection && !strings.HasPrefix(name, includeIfSection+" ") {
+				continue
+			}
+
+			for _, include := range section.Key(pathKey).ValueWithShadows() {
+				if include == "" {
+					continue
+				}
+
+				include, err := homedir.Expand(include)
+				if err != nil {
+					return err
+				}
+
+				if !filepath.IsAbs(include) {
+					include = filepath.Join(filepath.Dir(path), include)
+				}
+
+				if err := harvest(include); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	for _, path := range paths {
+		if err := harvest(path); err != nil {
+			return nil, err
+		}
+	}
+
+	return identities, nil
+}
+
+// Unknown returns the identities which are not profiles of the store yet
+func Unknown(store base.ProfileStore, identities []Identity) []Identity {
+	var unknown []Identity
+	for _, identity := range identities {
+		if existing, err := store.Get(identity.Name); err == nil && existing == identity.Entry {
+			continue
+		}
+		unknown = append(unknown, identity)
+	}
+
+	return unknown
+}
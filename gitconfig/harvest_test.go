@@ -0,0 +1,83 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gitconfig
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+func TestHarvest(t *testing.T) {
+	home := t.TempDir()
+	setenv(t, map[string]string{"HOME": home})
+
+	writeFiles(t, home, map[string]string{
+		".gitconfig": `[user]
+	name = Jane
+	email = jane@home.org
+[include]
+	path = conf/work
+	path = missing
+[includeIf "gitdir:~/oss/"]
+	path = ~/conf/oss
+`,
+		// Included files are read whatever the condition, and back again
+		"conf/work": "[User]\n\tName = Jane\n\tEMAIL = jane@corp.com\n[include]\n\tpath = ../.gitconfig\n",
+		"conf/oss":  "[user]\n\tname = Jane\n\temail = jane@home.org\n[include]\n\tpath = oss\n\tpath = shared\n",
+		// Incomplete identities are ignored
+		"conf/shared": "[user]\n\tname = Shared\n",
+		"repo/config": "[user]\n\tname = John\n\temail = john@corp.com\n[include]\n\tpath = ../conf/work\n",
+	})
+
+	identities, err := Harvest([]string{
+		filepath.Join(home, ".gitconfig"),
+		filepath.Join(home, "repo", "config"),
+		filepath.Join(home, "missing"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Identity{
+		{Entry: base.Entry{Name: "Jane", Email: "jane@home.org"}, Filename: filepath.Join(home, ".gitconfig")},
+		{Entry: base.Entry{Name: "Jane", Email: "jane@corp.com"}, Filename: filepath.Join(home, "conf", "work")},
+		{Entry: base.Entry{Name: "John", Email: "john@corp.com"}, Filename: filepath.Join(home, "repo", "config")},
+	}
+	if !reflect.DeepEqual(identities, want) {
+		t.Errorf("Harvest() = %v, want %v", identities, want)
+	}
+}
+
+func TestUnknown(t *testing.T) {
+	store := base.NewMemory(
+		base.Entry{Name: "Jane", Email: "jane@home.org"},
+		base.Entry{Name: "work", Email: "jane@corp.com"},
+	)
+
+	identities := []Identity{
+		{Entry: base.Entry{Name: "Jane", Email: "jane@home.org"}, Filename: "a"},
+		{Entry: base.Entry{Name: "Jane", Email: "jane@corp.com"}, Filename: "b"},
+		{Entry: base.Entry{Name: "John", Email: "john@corp.com"}, Filename: "c"},
+	}
+
+	got := Unknown(store, identities)
+	if want := identities[1:]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Unknown() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,249 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gitsync
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/config"
+	"github.com/tabarnhack/git-switch/transfer"
+)
+
+const (
+	profilesFile = "profiles.yml"
+	remoteName   = "origin"
+
+	// lastSyncRef points to the commit holding the profiles of the last sync
+	lastSyncRef = "refs/git-switch/last-sync"
+
+	// authorName signs the sync commits, which don't depend on the git
+	// identity of the machine
+	authorName = "git-switch"
+)
+
+var ErrNotInitialized = errors.New("profiles sync has not been initialized, run sync init first")
+
+// Repository is the local clone of the git repository the profiles are
+// synced through
+type Repository struct {
+	dir string
+
+	// pulled is the remote commit merged by the last pull, if any
+	pulled string
+}
+
+// Init clones the repository, which can be a URL or a local path, possibly
+// a bare and empty repository.
+func Init(conf config.SyncConfig, url string) (*Repository, error) {
+	if _, err := os.Stat(conf.Path); err == nil {
+		return nil, fmt.Errorf("profiles sync is already initialized in %s", conf.Path)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(conf.Path), 0700); err != nil {
+		return nil, err
+	}
+
+	// Local paths are made absolute as the clone lives in another directory
+	if _, err := os.Stat(url); err == nil {
+		if url, err = filepath.Abs(url); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := git("", "clone", "--quiet", url, conf.Path); err != nil {
+		return nil, err
+	}
+
+	return &Repository{dir: conf.Path}, nil
+}
+
+func Open(conf config.SyncConfig) (*Repository, error) {
+	if _, err := os.Stat(filepath.Join(conf.Path, ".git")); err != nil {
+		return nil, ErrNotInitialized
+	}
+
+	return &Repository{dir: conf.Path}, nil
+}
+
+// Pull merges the profiles of the remote repository with the local ones and
+// returns the merged profiles. The merge is committed in the local clone, to
+// be pushed; Saved must be called once the merged profiles have been saved.
+func (r *Repository) Pull(local []base.Entry, resolve Resolver) ([]base.Entry, error) {
+	if _, err := git(r.dir, "fetch", "--quiet", remoteName); err != nil {
+		return nil, err
+	}
+
+	branch, err := git(r.dir, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	remoteRef := remoteName + "/" + branch
+
+	// Nothing has been synced yet after init, every profile is new
+	last, err := r.profilesAt(lastSyncRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var remote []base.Entry
+	if r.exists(remoteRef) {
+		if remote, err = r.profilesAt(remoteRef); err != nil {
+			return nil, err
+		}
+		if r.pulled, err = git(r.dir, "rev-parse", remoteRef); err != nil {
+			return nil, err
+		}
+
+		// The clone never holds changes of its own, the merge result is
+		// committed on top of the remote history
+		if _, err := git(r.dir, "reset", "--quiet", "--hard", remoteRef); err != nil {
+			return nil, err
+		}
+	}
+
+	merged, err := Merge(last, local, remote, resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.commit(merged); err != nil {
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+// Saved makes the remote profiles merged by the last pull the base of the
+// next merge, once the merged profiles have been saved to the DB. The local
+// changes not pushed yet are then merged again on the next pull.
+func (r *Repository) Saved() error {
+	if r.pulled == "" {
+		return nil
+	}
+
+	_, err := git(r.dir, "update-ref", lastSyncRef, r.pulled)
+	return err
+}
+
+// Push sends the local clone to the remote repository, whose profiles are
+// then the base of the next merge
+func (r *Repository) Push() error {
+	branch, err := git(r.dir, "symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return err
+	}
+
+	if !r.exists("HEAD") {
+		return nil
+	}
+
+	if _, err := git(r.dir, "push", "--quiet", remoteName, "HEAD:"+branch); err != nil {
+		return err
+	}
+
+	_, err = git(r.dir, "update-ref", lastSyncRef, "HEAD")
+	return err
+}
+
+func (r *Repository) commit(entries []base.Entry) error {
+	var buf bytes.Buffer
+	if err := transfer.Encode(&buf, transfer.YAML, entries); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(r.dir, profilesFile), buf.Bytes(), 0600); err != nil {
+		return err
+	}
+
+	if _, err := git(r.dir, "add", profilesFile); err != nil {
+		return err
+	}
+
+	status, err := git(r.dir, "status", "--porcelain", "--", profilesFile)
+	if err != nil || status == "" {
+		return err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "localhost"
+	}
+
+	identity := []string{
+		"GIT_AUTHOR_NAME=" + authorName,
+		"GIT_AUTHOR_EMAIL=" + authorName + "@" + hostname,
+		"GIT_COMMITTER_NAME=" + authorName,
+		"GIT_COMMITTER_EMAIL=" + authorName + "@" + hostname,
+	}
+	_, err = run(r.dir, identity, "commit", "--quiet", "-m", "Sync git profiles from "+hostname)
+	return err
+}
+
+// profilesAt returns the profiles stored at the revision, none if the
+// revision or the file do not exist
+func (r *Repository) profilesAt(rev string) ([]base.Entry, error) {
+	if !r.exists(rev + ":" + profilesFile) {
+		return nil, nil
+	}
+
+	content, err := git(r.dir, "show", rev+":"+profilesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer.Decode(strings.NewReader(content), transfer.YAML)
+}
+
+func (r *Repository) exists(rev string) bool {
+	_, err := git(r.dir, "rev-parse", "--quiet", "--verify", rev)
+	return err == nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	return run(dir, nil, args...)
+}
+
+// run runs git with the variables added to the environment
+func run(dir string, env []string, args ...string) (string, error) {
+	subcommand := args[0]
+	if dir != "" {
+		args = append([]string{"-C", dir}, args...)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	if env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", subcommand, msg)
+		}
+		return "", err
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
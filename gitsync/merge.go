@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gitsync
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+// Conflict is a profile changed differently on both sides since the last
+// sync. A nil entry means the profile has been deleted on that side.
+type Conflict struct {
+	Name   string
+	Local  *base.Entry
+	Remote *base.Entry
+}
+
+// Resolver picks the version of a conflicting profile to keep, nil to delete it
+type Resolver func(c Conflict) (*base.Entry, error)
+
+// Sides of a sync a conflicting profile can be kept from
+const (
+	LocalSide  = "local"
+	RemoteSide = "remote"
+)
+
+// Prefer returns the resolver keeping the version of the given side, or
+// the fallback one when no side is given
+func Prefer(side string, fallback Resolver) (Resolver, error) {
+	switch side {
+	case LocalSide:
+		return func(c Conflict) (*base.Entry, error) { return c.Local, nil }, nil
+	case RemoteSide:
+		return func(c Conflict) (*base.Entry, error) { return c.Remote, nil }, nil
+	case "":
+		return fallback, nil
+	}
+
+	return nil, fmt.Errorf("unknown side %s, expected %s or %s", side, LocalSide, RemoteSide)
+}
+
+// Merge does a three-way merge of the profiles by name. Changes made on only
+// one side since the last sync are kept, the others are given to resolve by
+// name order.
+func Merge(last, local, remote []base.Entry, resolve Resolver) ([]base.Entry, error) {
+	lastByName, localByName, remoteByName := byName(last), byName(local), byName(remote)
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, entries := range []map[string]base.Entry{lastByName, localByName, remoteByName} {
+		for name := range entries {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	var merged []base.Entry
+	for _, name := range names {
+		l, r, b := lookup(localByName, name), lookup(remoteByName, name), lookup(lastByName, name)
+
+		var keep *base.Entry
+		switch {
+		case same(l, r), same(r, b):
+			keep = l
+		case same(l, b):
+			keep = r
+		default:
+			var err error
+			keep, err = resolve(Conflict{Name: name, Local: l, Remote: r})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if keep != nil {
+			merged = append(merged, *keep)
+		}
+	}
+
+	return merged, nil
+}
+
+func byName(entries []base.Entry) map[string]base.Entry {
+	m := make(map[string]base.Entry)
+	for _, entry := range entries {
+		m[entry.Name] = entry
+	}
+
+	return m
+}
+
+func lookup(entries map[string]base.Entry, name string) *base.Entry {
+	if entry, ok := entries[name]; ok {
+		return &entry
+	}
+
+	return nil
+}
+
+func same(a, b *base.Entry) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
@@ -0,0 +1,134 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package gitsync
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+func TestMerge(t *testing.T) {
+	work := base.Entry{Name: "work", Email: "jane@corp.com"}
+	newWork := base.Entry{Name: "work", Email: "jane@newcorp.com"}
+	otherWork := base.Entry{Name: "work", Email: "jane@othercorp.com"}
+	home := base.Entry{Name: "home", Email: "jane@home.org"}
+
+	keepLocal := func(c Conflict) (*base.Entry, error) { return c.Local, nil }
+	keepRemote := func(c Conflict) (*base.Entry, error) { return c.Remote, nil }
+	unexpected := func(c Conflict) (*base.Entry, error) {
+		return nil, errors.New("unexpected conflict on " + c.Name)
+	}
+
+	tests := []struct {
+		name                string
+		last, local, remote []base.Entry
+		resolve             Resolver
+		want                []base.Entry
+	}{
+		{"first sync", nil, []base.Entry{work}, []base.Entry{home}, unexpected, []base.Entry{home, work}},
+		{"unchanged", []base.Entry{work}, []base.Entry{work}, []base.Entry{work}, unexpected, []base.Entry{work}},
+		{"changed locally", []base.Entry{work}, []base.Entry{newWork}, []base.Entry{work}, unexpected, []base.Entry{newWork}},
+		{"changed remotely", []base.Entry{work}, []base.Entry{work}, []base.Entry{newWork}, unexpected, []base.Entry{newWork}},
+		{"same change on both sides", []base.Entry{work}, []base.Entry{newWork}, []base.Entry{newWork}, unexpected, []base.Entry{newWork}},
+		{"deleted locally", []base.Entry{work, home}, []base.Entry{home}, []base.Entry{work, home}, unexpected, []base.Entry{home}},
+		{"deleted remotely", []base.Entry{work, home}, []base.Entry{work, home}, []base.Entry{home}, unexpected, []base.Entry{home}},
+		{"added on both sides", []base.Entry{home}, []base.Entry{home, work}, []base.Entry{home, newWork}, keepRemote, []base.Entry{home, newWork}},
+		{"changed on both sides", []base.Entry{work}, []base.Entry{newWork}, []base.Entry{otherWork}, keepLocal, []base.Entry{newWork}},
+		{"changed and deleted", []base.Entry{work}, []base.Entry{newWork}, nil, keepRemote, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Merge(test.last, test.local, test.remote, test.resolve)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Merge() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMergeResolveError(t *testing.T) {
+	failed := errors.New("no answer")
+	_, err := Merge(
+		[]base.Entry{{Name: "work", Email: "jane@corp.com"}},
+		[]base.Entry{{Name: "work", Email: "jane@newcorp.com"}},
+		[]base.Entry{{Name: "work", Email: "jane@othercorp.com"}},
+		func(Conflict) (*base.Entry, error) { return nil, failed },
+	)
+	if !errors.Is(err, failed) {
+		t.Errorf("Merge() error = %v, want %v", err, failed)
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	last := []base.Entry{{Name: "work", Email: "jane@corp.com"}, {Name: "home", Email: "jane@home.org"}}
+	local := []base.Entry{{Name: "work", Email: "jane@newcorp.com"}, {Name: "home", Email: "jane@newhome.org"}}
+	remote := []base.Entry{{Name: "work", Email: "jane@othercorp.com"}}
+
+	// The local version is kept for the first conflict, the remote one for
+	// the next ones
+	var prompted []string
+	promptFirst := func(c Conflict) (*base.Entry, error) {
+		prompted = append(prompted, c.Name)
+		if len(prompted) == 1 {
+			return c.Local, nil
+		}
+		return c.Remote, nil
+	}
+
+	tests := []struct {
+		prefer   string
+		want     []base.Entry
+		prompted []string
+	}{
+		{"", []base.Entry{local[1], remote[0]}, []string{"home", "work"}},
+		{LocalSide, []base.Entry{local[1], local[0]}, nil},
+		{RemoteSide, remote, nil},
+	}
+
+	for _, test := range tests {
+		t.Run("prefer "+test.prefer, func(t *testing.T) {
+			prompted = nil
+			resolve, err := Prefer(test.prefer, promptFirst)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Merge(last, local, remote, resolve)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Merge() = %v, want %v", got, test.want)
+			}
+			if !reflect.DeepEqual(prompted, test.prompted) {
+				t.Errorf("Merge() prompted %v, want %v", prompted, test.prompted)
+			}
+		})
+	}
+}
+
+func TestPreferUnknownSide(t *testing.T) {
+	if _, err := Prefer("both", nil); err == nil {
+		t.Error("Prefer() error = nil, want an error")
+	}
+}
@@ -3,11 +3,18 @@ module github.com/tabarnhack/git-switch
 go 1.16
 
 require (
+	github.com/gdamore/tcell/v2 v2.3.3
 	github.com/manifoldco/promptui v0.8.0
 	github.com/mitchellh/go-homedir v1.1.0
+	github.com/pelletier/go-toml v1.9.3
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/pterm/pterm v0.12.30
+	github.com/rivo/tview v0.0.0-20210624165335-29d673af0ce2
 	github.com/spf13/cobra v1.2.1
 	github.com/spf13/viper v1.8.1
 	go.etcd.io/bbolt v1.3.6
+	golang.org/x/crypto v0.0.0-20211117183948-ae814b36b871
+	golang.org/x/term v0.0.0-20210615171337-6886f2dfbf5b
 	gopkg.in/ini.v1 v1.62.0
+	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
@@ -0,0 +1,130 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package print
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pterm/pterm"
+)
+
+// Level is the severity of a diagnostic message
+type Level int
+
+const (
+	// TraceLevel details every step of the resolutions, eg. each path tried
+	TraceLevel Level = iota
+	DebugLevel
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = map[Level]string{
+	TraceLevel: "TRACE",
+	DebugLevel: "DEBUG",
+	InfoLevel:  "INFO",
+	WarnLevel:  "WARN",
+	ErrorLevel: "ERROR",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+var (
+	level   = InfoLevel
+	logFile *os.File
+
+	tracePrinter = pterm.Debug.WithDebugger(false).WithPrefix(pterm.Prefix{Text: "TRACE", Style: pterm.Debug.Prefix.Style})
+	debugPrinter = pterm.Debug.WithDebugger(false)
+)
+
+// SetLevel hides the diagnostics less severe than the level
+func SetLevel(l Level) {
+	level = l
+}
+
+// OpenLogFile appends the diagnostics to the file with the time they are
+// written at. The debug messages, which record every write to a gitconfig
+// file, are logged whatever the level shown.
+func OpenLogFile(path string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	logFile = f
+	return nil
+}
+
+func Trace(v ...interface{}) {
+	log(TraceLevel, v...)
+}
+
+func Debug(v ...interface{}) {
+	log(DebugLevel, v...)
+}
+
+func Info(v ...interface{}) {
+	log(InfoLevel, v...)
+}
+
+func Warn(v ...interface{}) {
+	log(WarnLevel, v...)
+}
+
+// Error reports an error, as an ErrorResult in the machine-readable formats
+func Error(v ...interface{}) {
+	log(ErrorLevel, v...)
+}
+
+// log writes the message to the standard error, so that the standard output
+// only holds the results, and to the log file
+func log(l Level, v ...interface{}) {
+	msg := strings.TrimSuffix(fmt.Sprintln(v...), "\n")
+
+	if logFile != nil && (l >= DebugLevel || l >= level) {
+		fmt.Fprintf(logFile, "%s %-5s %s\n", time.Now().Format(time.RFC3339), l, msg)
+	}
+
+	if l < level || !pterm.Output {
+		return
+	}
+
+	if l == ErrorLevel && Machine() {
+		encode(os.Stderr, ErrorResult{Error: msg}, false)
+		return
+	}
+
+	var printer *pterm.PrefixPrinter
+	switch l {
+	case TraceLevel:
+		printer = tracePrinter
+	case DebugLevel:
+		printer = debugPrinter
+	case InfoLevel:
+		printer = &pterm.Info
+	case WarnLevel:
+		printer = &pterm.Warning
+	default:
+		printer = &pterm.Error
+	}
+	fmt.Fprint(os.Stderr, printer.Sprintln(msg))
+}
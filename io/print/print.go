@@ -16,6 +16,9 @@ limitations under the License.
 package print
 
 import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/pterm/pterm"
 )
 
@@ -25,7 +28,9 @@ func Println(v ...interface{}) {
 	pterm.FgCyan.Println(v...)
 }
 
-func Info(v ...interface{}) {
+// Notice writes an informative line of a result, unlike Info which writes
+// a diagnostic to the standard error
+func Notice(v ...interface{}) {
 	pterm.Info.Println(v...)
 }
 
@@ -33,8 +38,20 @@ func Success(v ...interface{}) {
 	pterm.Success.Println(v...)
 }
 
-func Error(v ...interface{}) {
-	pterm.Error.Println(v...)
+// DisableOutput silences every message, eg. while completing the command line
+func DisableOutput() {
+	pterm.DisableOutput()
+}
+
+// EnableOutput shows the messages again after DisableOutput
+func EnableOutput() {
+	pterm.EnableOutput()
+}
+
+// DisableStyling removes the colors and every other ANSI escape sequence
+// from the output
+func DisableStyling() {
+	pterm.DisableStyling()
 }
 
 func Table(d TableData) {
@@ -44,3 +61,41 @@ func Table(d TableData) {
 func Section(v ...interface{}) {
 	pterm.DefaultSection.Println(v...)
 }
+
+// UnifiedDiff returns the unified diff needed to go from the content a to b,
+// empty when both contents are identical
+func UnifiedDiff(aName, bName string, a, b []byte) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: aName,
+		ToFile:   bName,
+		Context:  3,
+	})
+}
+
+// Diff renders the unified diff needed to go from the content a to b.
+// It returns false when both contents are identical.
+func Diff(aName, bName string, a, b []byte) (bool, error) {
+	diff, err := UnifiedDiff(aName, bName, a, b)
+	if err != nil || diff == "" {
+		return false, err
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			pterm.Println(pterm.Bold.Sprint(line))
+		case strings.HasPrefix(line, "+"):
+			pterm.Println(pterm.FgGreen.Sprint(line))
+		case strings.HasPrefix(line, "-"):
+			pterm.Println(pterm.FgRed.Sprint(line))
+		case strings.HasPrefix(line, "@@"):
+			pterm.Println(pterm.FgCyan.Sprint(line))
+		default:
+			pterm.Println(line)
+		}
+	}
+
+	return true, nil
+}
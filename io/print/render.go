@@ -0,0 +1,134 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package print
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pterm/pterm"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats of the command results
+const (
+	TableOutput = "table"
+	JSONOutput  = "json"
+	YAMLOutput  = "yaml"
+	TSVOutput   = "tsv"
+)
+
+var OutputFormats = []string{TableOutput, JSONOutput, YAMLOutput, TSVOutput}
+
+var output = TableOutput
+
+// Result is the typed outcome of a command. It is encoded through its json
+// and yaml tags for tooling, and rendered from its rows in the other formats.
+type Result interface {
+	// Rows returns the result as a table, the first row being the header
+	Rows() TableData
+}
+
+// Presenter is implemented by the results shown to humans otherwise than as
+// a single table
+type Presenter interface {
+	Present()
+}
+
+// ErrorResult is how errors are reported in the machine-readable formats
+type ErrorResult struct {
+	Error string `json:"error" yaml:"error"`
+}
+
+func (e ErrorResult) Rows() TableData {
+	return TableData{{"Error"}, {e.Error}}
+}
+
+// SetOutput selects the format of the results. In the machine-readable
+// formats, the messages meant for humans are written to the standard error
+// so that only the results are written to the standard output.
+func SetOutput(format string) error {
+	format = strings.ToLower(format)
+	switch format {
+	case TableOutput:
+	case JSONOutput, YAMLOutput, TSVOutput:
+		pterm.SetDefaultOutput(os.Stderr)
+	default:
+		return fmt.Errorf("unknown output format %s, expected one of %s", format, strings.Join(OutputFormats, ", "))
+	}
+
+	output = format
+	return nil
+}
+
+// Output returns the selected output format
+func Output() string {
+	return output
+}
+
+// Machine reports whether the results are rendered for tooling
+func Machine() bool {
+	return output != TableOutput
+}
+
+// Render writes the result to the standard output in the selected format
+func Render(result Result) error {
+	if output == TableOutput {
+		if p, ok := result.(Presenter); ok {
+			p.Present()
+		} else if rows := result.Rows(); len(rows) > 1 {
+			Table(rows)
+		}
+		return nil
+	}
+
+	return encode(os.Stdout, result, true)
+}
+
+func encode(w io.Writer, result Result, indent bool) error {
+	switch output {
+	case JSONOutput:
+		enc := json.NewEncoder(w)
+		if indent {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(result)
+	case YAMLOutput:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	// Tabs and line breaks inside the cells would break the columns
+	escape := strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`)
+	for _, row := range result.Rows() {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = escape.Replace(cell)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(cells, "\t")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
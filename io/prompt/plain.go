@@ -0,0 +1,151 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Plain prompts with lines of text only, for terminals and screen readers
+// which can't handle interactive widgets. Every invalid answer is asked
+// again.
+type Plain struct {
+	in    *bufio.Reader
+	stdin io.Reader
+	out   io.Writer
+}
+
+func NewPlain(in io.Reader, out io.Writer) *Plain {
+	return &Plain{in: bufio.NewReader(in), stdin: in, out: out}
+}
+
+func (p *Plain) String(msg, defaultVal string, validate func(string) error) (string, error) {
+	label := msg + ": "
+	if defaultVal != "" {
+		label = fmt.Sprintf("%s [%s]: ", msg, defaultVal)
+	}
+
+	for {
+		answer, err := p.ask(label)
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = defaultVal
+		}
+
+		if err := validate(answer); err != nil {
+			fmt.Fprintln(p.out, "Invalid answer:", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// Password reads the answer without echoing it when the input is a terminal
+func (p *Plain) Password(msg string) (string, error) {
+	f, isFile := p.stdin.(*os.File)
+	for {
+		var answer string
+		var err error
+
+		if isFile && term.IsTerminal(int(f.Fd())) {
+			fmt.Fprint(p.out, msg+": ")
+			var input []byte
+			input, err = term.ReadPassword(int(f.Fd()))
+			fmt.Fprintln(p.out)
+			answer = string(input)
+		} else {
+			answer, err = p.ask(msg + ": ")
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if answer == "" {
+			fmt.Fprintln(p.out, "Invalid answer:", errors.New("Empty passphrase"))
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// Confirm defaults to no on an empty answer
+func (p *Plain) Confirm(msg string) (bool, error) {
+	for {
+		answer, err := p.ask(msg + " [y/N]: ")
+		if err != nil {
+			return false, err
+		}
+
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "", "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.out, "Invalid answer: expected y or n")
+	}
+}
+
+// Select lists the items numbered from 1 and asks for a number
+func (p *Plain) Select(msg string, items []Item) (int, error) {
+	if len(items) == 0 {
+		return 0, errors.New("nothing to select")
+	}
+
+	fmt.Fprintln(p.out, msg+":")
+	for i, item := range items {
+		fmt.Fprintf(p.out, "  %d) %s\n", i+1, item.Text())
+	}
+
+	for {
+		answer, err := p.ask(fmt.Sprintf("Choice [1-%d]: ", len(items)))
+		if err != nil {
+			return 0, err
+		}
+
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(items) {
+			return n - 1, nil
+		}
+		fmt.Fprintf(p.out, "Invalid answer: expected a number between 1 and %d\n", len(items))
+	}
+}
+
+// ask prints the label and reads the answer line, the end of the input
+// aborting the prompt
+func (p *Plain) ask(label string) (string, error) {
+	fmt.Fprint(p.out, label)
+
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		fmt.Fprintln(p.out)
+		return "", ErrAborted
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+
+	return strings.TrimSpace(line), nil
+}
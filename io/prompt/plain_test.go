@@ -0,0 +1,133 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package prompt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPlainString(t *testing.T) {
+	validate := func(s string) error {
+		if !strings.Contains(s, "@") {
+			return errors.New("not an email")
+		}
+		return nil
+	}
+
+	tests := []struct {
+		name       string
+		input      string
+		defaultVal string
+		want       string
+		invalid    int
+		err        error
+	}{
+		{"valid", "jane@example.com\n", "", "jane@example.com", 0, nil},
+		{"asked again", "jane\n\njane@example.com\n", "", "jane@example.com", 2, nil},
+		{"default", "\n", "jane@example.com", "jane@example.com", 0, nil},
+		{"trimmed", "  jane@example.com  \n", "", "jane@example.com", 0, nil},
+		{"last line", "jane@example.com", "", "jane@example.com", 0, nil},
+		{"end of input", "jane\n", "", "", 1, ErrAborted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			got, err := NewPlain(strings.NewReader(test.input), &out).String("Email", test.defaultVal, validate)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("String() error = %v, want %v", err, test.err)
+			}
+			if got != test.want {
+				t.Errorf("String() = %q, want %q", got, test.want)
+			}
+			if n := strings.Count(out.String(), "Invalid answer"); n != test.invalid {
+				t.Errorf("String() asked again %d times, want %d", n, test.invalid)
+			}
+		})
+	}
+}
+
+func TestPlainConfirm(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    bool
+		invalid int
+		err     error
+	}{
+		{"yes", "y\n", true, 0, nil},
+		{"no", "No\n", false, 0, nil},
+		{"empty", "\n", false, 0, nil},
+		{"asked again", "maybe\nyes\n", true, 1, nil},
+		{"end of input", "", false, 0, ErrAborted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			got, err := NewPlain(strings.NewReader(test.input), &out).Confirm("Continue?")
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Confirm() error = %v, want %v", err, test.err)
+			}
+			if got != test.want {
+				t.Errorf("Confirm() = %v, want %v", got, test.want)
+			}
+			if n := strings.Count(out.String(), "Invalid answer"); n != test.invalid {
+				t.Errorf("Confirm() asked again %d times, want %d", n, test.invalid)
+			}
+		})
+	}
+}
+
+func TestPlainSelect(t *testing.T) {
+	items := Items("work", "home", "school")
+
+	tests := []struct {
+		name    string
+		input   string
+		want    int
+		invalid int
+		err     error
+	}{
+		{"first", "1\n", 0, 0, nil},
+		{"last", "3\n", 2, 0, nil},
+		{"out of range", "0\n4\n2\n", 1, 2, nil},
+		{"label", "home\n2\n", 1, 1, nil},
+		{"end of input", "5\n", 0, 1, ErrAborted},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			got, err := NewPlain(strings.NewReader(test.input), &out).Select("Profile", items)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Select() error = %v, want %v", err, test.err)
+			}
+			if got != test.want {
+				t.Errorf("Select() = %d, want %d", got, test.want)
+			}
+			if n := strings.Count(out.String(), "Invalid answer"); n != test.invalid {
+				t.Errorf("Select() asked again %d times, want %d", n, test.invalid)
+			}
+		})
+	}
+
+	if _, err := NewPlain(strings.NewReader("1\n"), &strings.Builder{}).Select("Profile", nil); err == nil {
+		t.Error("Select() without items succeeded")
+	}
+}
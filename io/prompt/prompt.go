@@ -17,11 +17,137 @@ package prompt
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"strings"
 
-	"github.com/manifoldco/promptui"
+	"golang.org/x/term"
 )
 
+// Prompter asks the user for the answers the commands need
+type Prompter interface {
+	// String asks for a string, accepted once validate returns no error
+	String(msg, defaultVal string, validate func(string) error) (string, error)
+	// Password asks for a string without echoing it
+	Password(msg string) (string, error)
+	// Confirm asks a yes or no question
+	Confirm(msg string) (bool, error)
+	// Select asks to choose one of the items and returns its index
+	Select(msg string, items []Item) (int, error)
+}
+
+// Item is a choice offered by Select. The note is shown after the label,
+// the details beside the highlighted item. The search matches all of them.
+type Item struct {
+	Label   string
+	Note    string
+	Details []Detail
+}
+
+// Detail is a named value describing an item
+type Detail struct {
+	Name  string
+	Value string
+}
+
+// Items returns the items holding the labels only
+func Items(labels ...string) []Item {
+	items := make([]Item, 0, len(labels))
+	for _, label := range labels {
+		items = append(items, Item{Label: label})
+	}
+
+	return items
+}
+
+// Text returns the label followed by the note
+func (i Item) Text() string {
+	if i.Note == "" {
+		return i.Label
+	}
+
+	return i.Label + " (" + i.Note + ")"
+}
+
+// Matches reports whether the item contains the search, ignoring the case
+// and the spaces
+func (i Item) Matches(search string) bool {
+	normalize := func(s string) string {
+		return strings.Replace(strings.ToLower(s), " ", "", -1)
+	}
+
+	search = normalize(search)
+	if strings.Contains(normalize(i.Text()), search) {
+		return true
+	}
+	for _, d := range i.Details {
+		if strings.Contains(normalize(d.Value), search) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	prompter    Prompter = NewPromptui(os.Stdin, os.Stdout)
+	interactive          = true
+	assumeYes   bool
+
+	// ErrAborted is returned when the user interrupts a prompt
+	ErrAborted = errors.New("aborted by the user")
+)
+
+// NoInputError is returned when an answer is needed while prompting is
+// disabled. Flag tells how to give the answer instead, if known.
+type NoInputError struct {
+	Prompt string
+	Flag   string
+}
+
+func (e *NoInputError) Error() string {
+	if e.Flag == "" {
+		return fmt.Sprintf("%q needs an answer but prompting is disabled", e.Prompt)
+	}
+
+	return fmt.Sprintf("%q needs an answer but prompting is disabled, use %s", e.Prompt, e.Flag)
+}
+
+// SetPrompter changes how the user is prompted
+func SetPrompter(p Prompter) {
+	prompter = p
+}
+
+// SetMode enables or disables the prompts. When yes is set, confirmations
+// are answered yes without prompting.
+func SetMode(enabled, yes bool) {
+	interactive = enabled
+	assumeYes = yes
+}
+
+// Interactive reports whether the user can be prompted
+func Interactive() bool {
+	return interactive
+}
+
+// IsTerminal reports whether the standard input is a terminal
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// WithFlag names the flag giving the answer of a prompt which could not be
+// shown. Other errors are returned as is.
+func WithFlag(err error, flag string) error {
+	var noInput *NoInputError
+	if errors.As(err, &noInput) {
+		return &NoInputError{Prompt: noInput.Prompt, Flag: flag}
+	}
+
+	return err
+}
+
+// PromptString asks for a non empty string. Without prompts, the default
+// value is used if it is valid.
 func PromptString(msg, defaultVal string, moreValidate func(string) error) (string, error) {
 	validate := func(input string) error {
 		if len(strings.TrimSpace(input)) == 0 {
@@ -37,27 +163,43 @@ func PromptString(msg, defaultVal string, moreValidate func(string) error) (stri
 		return nil
 	}
 
-	prompt := promptui.Prompt{
-		Label:     msg,
-		Default:   defaultVal,
-		AllowEdit: true,
-		Validate:  validate,
+	if !interactive {
+		if defaultVal != "" && validate(defaultVal) == nil {
+			return defaultVal, nil
+		}
+		return "", &NoInputError{Prompt: msg}
 	}
 
-	result, err := prompt.Run()
+	result, err := prompter.String(msg, defaultVal, validate)
 	return strings.TrimSpace(result), err
 }
 
+func PromptPassword(msg string) (string, error) {
+	if !interactive {
+		return "", &NoInputError{Prompt: msg}
+	}
+
+	return prompter.Password(msg)
+}
+
+// Confirm asks a yes or no question. Without prompts, the answer is yes if
+// assumed, otherwise the --yes flag is required.
 func Confirm(msg string) (bool, error) {
-	prompt := promptui.Prompt{
-		Label:     msg,
-		IsConfirm: true,
+	if assumeYes {
+		return true, nil
 	}
+	if !interactive {
+		return false, &NoInputError{Prompt: msg, Flag: "--yes"}
+	}
+
+	return prompter.Confirm(msg)
+}
 
-	result, err := prompt.Run()
-	if err != nil && err.Error() != "" {
-		return false, err
+// Select asks to choose one of the items and returns its index
+func Select(msg string, items []Item) (int, error) {
+	if !interactive {
+		return 0, &NoInputError{Prompt: msg}
 	}
 
-	return result == "y", nil
+	return prompter.Select(msg, items)
 }
@@ -0,0 +1,124 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package prompt
+
+import (
+	"errors"
+	"io"
+
+	"github.com/manifoldco/promptui"
+)
+
+// Promptui prompts with the interactive widgets of promptui
+type Promptui struct {
+	in  io.ReadCloser
+	out io.WriteCloser
+
+	// PageSize is the number of items listed at once by Select
+	PageSize int
+}
+
+func NewPromptui(in io.ReadCloser, out io.WriteCloser) *Promptui {
+	return &Promptui{in: in, out: out, PageSize: 5}
+}
+
+func (p *Promptui) String(msg, defaultVal string, validate func(string) error) (string, error) {
+	prompt := promptui.Prompt{
+		Label:     msg,
+		Default:   defaultVal,
+		AllowEdit: true,
+		Validate:  validate,
+		Stdin:     p.in,
+		Stdout:    p.out,
+	}
+
+	result, err := prompt.Run()
+	return result, aborted(err)
+}
+
+func (p *Promptui) Password(msg string) (string, error) {
+	prompt := promptui.Prompt{
+		Label: msg,
+		Mask:  '*',
+		Validate: func(input string) error {
+			if len(input) == 0 {
+				return errors.New("Empty passphrase")
+			}
+			return nil
+		},
+		Stdin:  p.in,
+		Stdout: p.out,
+	}
+
+	result, err := prompt.Run()
+	return result, aborted(err)
+}
+
+func (p *Promptui) Confirm(msg string) (bool, error) {
+	prompt := promptui.Prompt{
+		Label:     msg,
+		IsConfirm: true,
+		Stdin:     p.in,
+		Stdout:    p.out,
+	}
+
+	// A negative answer is reported as an empty error
+	result, err := prompt.Run()
+	if err != nil && err.Error() != "" {
+		return false, aborted(err)
+	}
+
+	return result == "y", nil
+}
+
+// Select shows the items in a scrolling list, which can be searched with /,
+// and the details of the highlighted one below
+func (p *Promptui) Select(msg string, items []Item) (int, error) {
+	templates := &promptui.SelectTemplates{
+		Label:    "{{ . }}?",
+		Active:   "→ {{ .Label | cyan | bold }}{{ if .Note }} {{ printf \"(%s)\" .Note | faint }}{{ end }}",
+		Inactive: "  {{ .Label | cyan }}{{ if .Note }} {{ printf \"(%s)\" .Note | faint }}{{ end }}",
+		Selected: "→ {{ .Label | cyan }}",
+		Details: `{{ range .Details }}
+{{ printf "%-10s" .Name | faint }} {{ .Value }}{{ end }}`,
+	}
+
+	searcher := func(input string, index int) bool {
+		return items[index].Matches(input)
+	}
+
+	prompt := promptui.Select{
+		Label:     msg,
+		Items:     items,
+		Templates: templates,
+		Size:      p.PageSize,
+		Searcher:  searcher,
+		Stdin:     p.in,
+		Stdout:    p.out,
+	}
+
+	i, _, err := prompt.Run()
+	return i, aborted(err)
+}
+
+// aborted converts the interruptions of promptui to ErrAborted
+func aborted(err error) error {
+	if errors.Is(err, promptui.ErrInterrupt) || errors.Is(err, promptui.ErrEOF) {
+		return ErrAborted
+	}
+
+	return err
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package prompt
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Answer is a scripted answer. When Prompt is set, the answer is only given
+// to a prompt whose message starts with it.
+type Answer struct {
+	Prompt string
+	Answer string
+}
+
+// UnmarshalYAML reads an answer given as a plain value, or as a mapping with
+// the prompt and the answer keys
+func (a *Answer) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		a.Answer = node.Value
+		return nil
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: %s must be a plain value", value.Line, key.Value)
+			}
+
+			switch key.Value {
+			case "prompt":
+				a.Prompt = value.Value
+			case "answer":
+				a.Answer = value.Value
+			default:
+				return fmt.Errorf("line %d: unknown key %s, expected prompt or answer", key.Line, key.Value)
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("line %d: an answer must be a plain value or a mapping", node.Line)
+}
+
+// Script answers the prompts from a list of answers, given in order. It never
+// asks again, an invalid answer is an error.
+type Script struct {
+	answers []Answer
+}
+
+func NewScript(answers ...Answer) *Script {
+	return &Script{answers: answers}
+}
+
+// LoadScript reads the answers from a YAML list, eg.
+//
+//   - Jane Doe
+//   - prompt: Email
+//     answer: jane@example.com
+func LoadScript(r io.Reader) (*Script, error) {
+	var answers []Answer
+	if err := yaml.NewDecoder(r).Decode(&answers); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("cannot read answers: %s", err)
+	}
+
+	return NewScript(answers...), nil
+}
+
+func (s *Script) String(msg, defaultVal string, validate func(string) error) (string, error) {
+	answer, err := s.next(msg)
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		answer = defaultVal
+	}
+
+	if err := validate(answer); err != nil {
+		return "", fmt.Errorf("invalid scripted answer for %q: %s", msg, err)
+	}
+	return answer, nil
+}
+
+func (s *Script) Password(msg string) (string, error) {
+	answer, err := s.next(msg)
+	if err == nil && answer == "" {
+		err = fmt.Errorf("invalid scripted answer for %q: Empty passphrase", msg)
+	}
+
+	return answer, err
+}
+
+func (s *Script) Confirm(msg string) (bool, error) {
+	answer, err := s.next(msg)
+	if err != nil {
+		return false, err
+	}
+
+	switch strings.ToLower(answer) {
+	case "y", "yes", "true":
+		return true, nil
+	case "n", "no", "false":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid scripted answer for %q: expected yes or no, got %q", msg, answer)
+}
+
+// Select picks the item equal to the answer, or else the item numbered by
+// the answer starting from 1
+func (s *Script) Select(msg string, items []Item) (int, error) {
+	answer, err := s.next(msg)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, item := range items {
+		if item.Label == answer || item.Text() == answer {
+			return i, nil
+		}
+	}
+
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(items) {
+		return n - 1, nil
+	}
+	return 0, fmt.Errorf("invalid scripted answer for %q: %q is not one of the %d items", msg, answer, len(items))
+}
+
+func (s *Script) next(msg string) (string, error) {
+	if len(s.answers) == 0 {
+		return "", fmt.Errorf("no scripted answer left for %q", msg)
+	}
+
+	a := s.answers[0]
+	if !strings.HasPrefix(msg, a.Prompt) {
+		return "", fmt.Errorf("the next scripted answer is for %q, not %q", a.Prompt, msg)
+	}
+
+	s.answers = s.answers[1:]
+	return a.Answer, nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScriptNext(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers []Answer
+		msgs    []string
+		want    []string
+		wantErr bool
+	}{
+		{"any prompt", []Answer{{Answer: "a"}, {Answer: "b"}}, []string{"Name", "Email"}, []string{"a", "b"}, false},
+		{"matching prompt", []Answer{{Prompt: "Name", Answer: "a"}}, []string{"Name"}, []string{"a"}, false},
+		{"prompt prefix", []Answer{{Prompt: "Email", Answer: "a"}}, []string{"Email of the profile"}, []string{"a"}, false},
+		{"other prompt", []Answer{{Prompt: "Email", Answer: "a"}}, []string{"Name"}, nil, true},
+		{"case differs", []Answer{{Prompt: "name", Answer: "a"}}, []string{"Name"}, nil, true},
+		{"no answer left", []Answer{{Answer: "a"}}, []string{"Name", "Email"}, []string{"a"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := NewScript(test.answers...)
+
+			var got []string
+			var err error
+			for _, msg := range test.msgs {
+				var answer string
+				if answer, err = s.next(msg); err != nil {
+					break
+				}
+				got = append(got, answer)
+			}
+
+			if (err != nil) != test.wantErr {
+				t.Fatalf("next() error = %v, want error %v", err, test.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(test.want, ",") {
+				t.Errorf("next() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLoadScript(t *testing.T) {
+	s, err := LoadScript(strings.NewReader("- Jane Doe\n- prompt: Email\n  answer: jane@example.com\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Answer{{Answer: "Jane Doe"}, {Prompt: "Email", Answer: "jane@example.com"}}
+	if len(s.answers) != len(want) || s.answers[0] != want[0] || s.answers[1] != want[1] {
+		t.Errorf("LoadScript() answers = %v, want %v", s.answers, want)
+	}
+
+	if _, err := LoadScript(strings.NewReader("- prompt: Email\n  reply: jane@example.com\n")); err == nil {
+		t.Error("LoadScript() with an unknown key succeeded")
+	}
+}
+
+func TestScriptSelect(t *testing.T) {
+	items := []Item{{Label: "work", Note: "jane@work.com"}, {Label: "home"}, {Label: "2"}}
+
+	tests := []struct {
+		answer  string
+		want    int
+		wantErr bool
+	}{
+		{"home", 1, false},
+		{"work (jane@work.com)", 0, false},
+		{"1", 0, false},
+		{"2", 2, false},
+		{"3", 2, false},
+		{"4", 0, true},
+		{"0", 0, true},
+		{"Home", 0, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.answer, func(t *testing.T) {
+			got, err := NewScript(Answer{Answer: test.answer}).Select("Profile", items)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Select() error = %v, want error %v", err, test.wantErr)
+			}
+			if err == nil && got != test.want {
+				t.Errorf("Select() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestScriptConfirm(t *testing.T) {
+	tests := []struct {
+		answer  string
+		want    bool
+		wantErr bool
+	}{
+		{"y", true, false},
+		{"Yes", true, false},
+		{"true", true, false},
+		{"n", false, false},
+		{"NO", false, false},
+		{"", false, true},
+		{"maybe", false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.answer, func(t *testing.T) {
+			got, err := NewScript(Answer{Answer: test.answer}).Confirm("Continue?")
+			if (err != nil) != test.wantErr {
+				t.Fatalf("Confirm() error = %v, want error %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("Confirm() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -16,10 +16,9 @@ limitations under the License.
 package user
 
 import (
-	"net/mail"
-	"strings"
+	"sort"
+	"time"
 
-	"github.com/manifoldco/promptui"
 	"github.com/tabarnhack/git-switch/base"
 	"github.com/tabarnhack/git-switch/io/prompt"
 )
@@ -27,54 +26,80 @@ import (
 func CreateUser(prev base.Entry, isEdit bool) (base.Entry, error) {
 	var err error
 	if prev.Name == "" || isEdit {
-		prev.Name, err = prompt.PromptString("Name", prev.Name, nil)
+		prev.Name, err = prompt.PromptString("Name", prev.Name, base.ValidateName)
 		if err != nil {
-			return base.Entry{}, err
+			return base.Entry{}, prompt.WithFlag(err, "--name")
 		}
 	}
 
 	if prev.Email == "" || isEdit {
-		prev.Email, err = prompt.PromptString("Email", prev.Email, func(input string) error {
-			_, err := mail.ParseAddress(input)
-			return err
-		})
+		prev.Email, err = prompt.PromptString("Email", prev.Email, base.ValidateEmail)
 		if err != nil {
-			return base.Entry{}, err
+			return base.Entry{}, prompt.WithFlag(err, "--email")
 		}
 	}
 
 	return prev, nil
 }
 
-func SelectUser(usersDB *base.Base, msg string) (base.Entry, error) {
-	entries := usersDB.List()
+// Selection describes how the profiles are offered by SelectEntry
+type Selection struct {
+	// Current is the profile marked as set in the target gitconfig file
+	Current base.Entry
+	// LastUsed lists the most recently used profiles first
+	LastUsed map[string]time.Time
+	// Origin returns the database holding the profile, if there are several
+	Origin func(name string) string
+}
 
-	templates := &promptui.SelectTemplates{
-		Label:    "{{ . }}?",
-		Active:   "→ {{ .Name | cyan | bold }}: {{ .Email | red }}",
-		Inactive: "  {{ .Name | cyan }}: {{ .Email | red }}",
-		Selected: "→ {{ .Name | red | cyan }}",
+func SelectUser(usersDB base.ProfileStore, msg string, sel Selection) (base.Entry, error) {
+	if layered, ok := usersDB.(*base.Layered); ok && sel.Origin == nil {
+		sel.Origin = layered.Origin
 	}
 
-	searcher := func(input string, index int) bool {
-		entry := entries[index]
-		name := strings.Replace(strings.ToLower(entry.Name), " ", "", -1)
-		input = strings.Replace(strings.ToLower(input), " ", "", -1)
+	return SelectEntry(usersDB.List(), msg, sel)
+}
 
-		return strings.Contains(name, input)
-	}
+// SelectEntry asks to choose one of the given profiles, ordered from the
+// most recently used one then by name
+func SelectEntry(entries []base.Entry, msg string, sel Selection) (base.Entry, error) {
+	entries = append([]base.Entry(nil), entries...)
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := sel.LastUsed[entries[i].Name], sel.LastUsed[entries[j].Name]
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return entries[i].Name < entries[j].Name
+	})
+
+	items := make([]prompt.Item, 0, len(entries))
+	for _, entry := range entries {
+		item := prompt.Item{
+			Label: entry.String(),
+			Details: []prompt.Detail{
+				{Name: "Name", Value: entry.Name},
+				{Name: "Email", Value: entry.Email},
+			},
+		}
+		if sel.Origin != nil {
+			item.Details = append(item.Details, prompt.Detail{Name: "Origin", Value: sel.Origin(entry.Name)})
+		}
 
-	prompt := promptui.Select{
-		Label:     "Git profiles",
-		Items:     entries,
-		Templates: templates,
-		Size:      5,
-		Searcher:  searcher,
+		lastUsed := "never"
+		if t, ok := sel.LastUsed[entry.Name]; ok {
+			lastUsed = t.Local().Format("2006-01-02 15:04")
+		}
+		item.Details = append(item.Details, prompt.Detail{Name: "Last used", Value: lastUsed})
+
+		if entry == sel.Current {
+			item.Note = "current"
+		}
+		items = append(items, item)
 	}
 
-	i, _, err := prompt.Run()
+	i, err := prompt.Select(msg, items)
 	if err != nil {
-		return base.Entry{}, err
+		return base.Entry{}, prompt.WithFlag(err, "--name")
 	}
 
 	return entries[i], nil
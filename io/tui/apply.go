@@ -0,0 +1,137 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/gitconfig"
+	"github.com/tabarnhack/git-switch/io/print"
+)
+
+// showApply lets the user pick the gitconfig file the profile is written to,
+// previewing the changes to the file picked
+func (m *Manager) showApply(entry base.Entry) {
+	diff := tview.NewTextView().SetDynamicColors(true)
+	diff.SetBorder(true).SetTitle(" Changes ")
+
+	options := make([]string, 0, len(m.targets))
+	for _, target := range m.targets {
+		options = append(options, target.String())
+	}
+
+	form := tview.NewForm().
+		AddDropDown("Target", options, m.target, func(_ string, index int) {
+			if index >= 0 {
+				m.target = index
+				diff.SetText(preview(entry, m.targets[index])).ScrollToBeginning()
+			}
+		})
+
+	form.AddButton("Apply", func() {
+		target := m.targets[m.target]
+		if err := m.apply(entry, target); err != nil {
+			diff.SetText("[red]" + tview.Escape("Can't apply the profile: "+err.Error()) + "[-]")
+			return
+		}
+
+		m.closePage(applyPage)
+		m.setStatus("[green]" + tview.Escape("Applied "+entry.String()+" to "+target.Path) + "[-]  " + help)
+		m.loadUsage()
+		m.showDetails()
+	}).
+		AddButton("Cancel", func() {
+			m.closePage(applyPage)
+		}).
+		SetCancelFunc(func() {
+			m.closePage(applyPage)
+		})
+
+	form.SetBorder(true).SetTitle(" Apply " + entry.Name + " ")
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(form, 7, 0, true).
+		AddItem(diff, 0, 1, false)
+
+	m.pages.AddPage(applyPage, layout, true, true)
+	m.app.SetFocus(form)
+}
+
+// apply writes the profile to the gitconfig file, keeping a backup of it.
+// The use is recorded even when the profile was already set.
+func (m *Manager) apply(entry base.Entry, target Target) error {
+	g, err := gitconfig.New(target.Path, false)
+	if err != nil {
+		return err
+	}
+	g.Journal = m.journal
+	g.Entry = entry
+
+	if err := g.Save(); err != nil {
+		return err
+	}
+
+	return m.journal.Used(entry.Name, base.Names(m.store))
+}
+
+// preview returns the colored diff of the changes applying the profile would
+// make to the gitconfig file
+func preview(entry base.Entry, target Target) string {
+	g, err := gitconfig.New(target.Path, true)
+	if err != nil {
+		return "[red]" + tview.Escape("Can't load gitconfig file: "+err.Error()) + "[-]"
+	}
+
+	curr, err := os.ReadFile(target.Path)
+	if err != nil {
+		return "[red]" + tview.Escape(err.Error()) + "[-]"
+	}
+
+	g.Entry = entry
+	next, err := g.Render()
+	if err != nil {
+		return "[red]" + tview.Escape(err.Error()) + "[-]"
+	}
+
+	diff, err := print.UnifiedDiff(target.Path, target.Path+" (new)", curr, next)
+	if err != nil {
+		return "[red]" + tview.Escape(err.Error()) + "[-]"
+	}
+	if diff == "" {
+		return tview.Escape("No changes to write to " + target.Path)
+	}
+
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		color := "-"
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			color = "::b"
+		case strings.HasPrefix(line, "+"):
+			color = "green"
+		case strings.HasPrefix(line, "-"):
+			color = "red"
+		case strings.HasPrefix(line, "@@"):
+			color = "aqua"
+		}
+		b.WriteString("[" + color + "]" + tview.Escape(line) + "[-:-:-]\n")
+	}
+
+	return b.String()
+}
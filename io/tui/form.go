@@ -0,0 +1,98 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tui
+
+import (
+	"strings"
+
+	"github.com/rivo/tview"
+	"github.com/tabarnhack/git-switch/base"
+)
+
+// showForm edits the fields of the profile, which are checked like when
+// creating a profile from the command line before being stored by save. When
+// the database can't be saved, undo reverts the change and the form stays
+// open.
+func (m *Manager) showForm(title string, entry base.Entry, save, undo func(base.Entry) error) {
+	form := tview.NewForm().
+		AddInputField("Name", entry.Name, 40, nil, nil).
+		AddInputField("Email", entry.Email, 40, nil, nil)
+
+	field := func(label string) string {
+		return strings.TrimSpace(form.GetFormItemByLabel(label).(*tview.InputField).GetText())
+	}
+
+	form.AddButton("Save", func() {
+		curr := base.Entry{Name: field("Name"), Email: field("Email")}
+		if err := base.ValidateName(curr.Name); err != nil {
+			m.setError("Invalid name:", err)
+			return
+		}
+		if err := base.ValidateEmail(curr.Email); err != nil {
+			m.setError("Invalid email:", err)
+			return
+		}
+
+		if err := save(curr); err != nil {
+			m.setError("Can't save the profile:", err)
+			return
+		}
+
+		if !m.save("Saved " + curr.String()) {
+			if err := undo(curr); err != nil {
+				m.setError("Can't undo the change:", err)
+			}
+			return
+		}
+
+		m.closePage(formPage)
+		m.refresh(curr.Name)
+	}).
+		AddButton("Cancel", func() {
+			m.closePage(formPage)
+		}).
+		SetCancelFunc(func() {
+			m.closePage(formPage)
+		})
+
+	form.SetBorder(true).SetTitle(" " + title + " ")
+
+	m.pages.AddPage(formPage, center(form, 60, 9), true, true)
+	m.app.SetFocus(form)
+}
+
+// confirmDelete removes the profile once confirmed
+func (m *Manager) confirmDelete(entry base.Entry) {
+	modal := tview.NewModal().
+		SetText("Delete the profile " + entry.String() + "?").
+		AddButtons([]string{"Delete", "Cancel"}).
+		SetDoneFunc(func(index int, label string) {
+			m.closePage(formPage)
+			if label != "Delete" {
+				return
+			}
+
+			if err := m.store.Delete(entry.Name); err != nil {
+				m.setError("Can't delete the profile:", err)
+				return
+			}
+			m.save("Deleted " + entry.String())
+			m.refresh("")
+		})
+
+	m.pages.AddPage(formPage, modal, true, true)
+	m.app.SetFocus(modal)
+}
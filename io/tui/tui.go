@@ -0,0 +1,323 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/gitconfig"
+	"github.com/tabarnhack/git-switch/journal"
+)
+
+const (
+	mainPage  = "main"
+	formPage  = "form"
+	applyPage = "apply"
+
+	help = "[::b]/[::-] filter  [::b]n[::-] new  [::b]e[::-] edit  [::b]c[::-] duplicate  [::b]d[::-] delete  [::b]enter[::-] apply  [::b]q[::-] quit"
+)
+
+// Target is a gitconfig file the profiles can be applied to
+type Target struct {
+	Name string
+	Path string
+}
+
+func (t Target) String() string {
+	return fmt.Sprintf("%s (%s)", t.Name, t.Path)
+}
+
+// Manager is a full-screen terminal app to browse and edit the profiles of
+// the database, and to apply them to the gitconfig files
+type Manager struct {
+	store   base.ProfileStore
+	journal *journal.Journal
+	targets []Target
+	target  int
+
+	app     *tview.Application
+	pages   *tview.Pages
+	filter  *tview.InputField
+	list    *tview.List
+	details *tview.TextView
+	status  *tview.TextView
+
+	// entries are the profiles shown in the list, in the same order
+	entries []base.Entry
+
+	// lastUsed and active, the profile set in each target, are read when the
+	// app starts and after applying a profile, not on every cursor move
+	lastUsed map[string]time.Time
+	active   []base.Entry
+}
+
+// New returns the manager of the profiles of the store. The target at the
+// given index is the one selected first when applying a profile.
+func New(store base.ProfileStore, j *journal.Journal, targets []Target, target int) *Manager {
+	m := &Manager{
+		store:   store,
+		journal: j,
+		targets: targets,
+		target:  target,
+		app:     tview.NewApplication(),
+		pages:   tview.NewPages(),
+		filter:  tview.NewInputField(),
+		list:    tview.NewList(),
+		details: tview.NewTextView(),
+		status:  tview.NewTextView(),
+	}
+
+	m.filter.SetLabel("Filter: ").
+		SetChangedFunc(func(string) {
+			m.refresh(m.selectedName())
+		}).
+		SetDoneFunc(func(tcell.Key) {
+			m.app.SetFocus(m.list)
+		})
+
+	m.list.SetHighlightFullLine(true).
+		SetChangedFunc(func(int, string, string, rune) {
+			m.showDetails()
+		}).
+		SetInputCapture(m.handleKey).
+		SetBorder(true).
+		SetTitle(" Profiles ")
+
+	m.details.SetDynamicColors(true).
+		SetBorder(true).
+		SetTitle(" Details ")
+
+	m.status.SetDynamicColors(true)
+	m.setStatus(help)
+
+	profiles := tview.NewFlex().
+		AddItem(m.list, 0, 1, true).
+		AddItem(m.details, 0, 2, false)
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(m.filter, 1, 0, false).
+		AddItem(profiles, 0, 1, true).
+		AddItem(m.status, 1, 0, false)
+
+	m.pages.AddPage(mainPage, layout, true, true)
+	m.app.SetRoot(m.pages, true).SetFocus(m.list)
+
+	return m
+}
+
+// Run shows the app until the user quits
+func (m *Manager) Run() error {
+	m.loadUsage()
+	m.refresh("")
+	return m.app.Run()
+}
+
+// loadUsage reads when the profiles were last used and the profile set in
+// each target
+func (m *Manager) loadUsage() {
+	m.lastUsed, _ = m.journal.LastUsed()
+
+	m.active = make([]base.Entry, len(m.targets))
+	for i, target := range m.targets {
+		if g, err := gitconfig.New(target.Path, true); err == nil {
+			m.active[i] = g.Entry
+		}
+	}
+}
+
+// handleKey runs the action bound to the key pressed in the list
+func (m *Manager) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyEnter {
+		if entry, ok := m.selected(); ok {
+			m.showApply(entry)
+		}
+		return nil
+	}
+
+	entry, ok := m.selected()
+	switch event.Rune() {
+	case '/':
+		m.app.SetFocus(m.filter)
+	case 'n':
+		m.showForm("New profile", base.Entry{}, m.store.Add, m.removeEntry)
+	case 'e':
+		if ok {
+			m.showForm("Edit "+entry.Name, entry, func(curr base.Entry) error {
+				return m.store.Update(entry, curr)
+			}, func(curr base.Entry) error {
+				return m.restoreEntry(curr, entry)
+			})
+		}
+	case 'c':
+		if ok {
+			duplicate := base.Entry{Name: entry.Name + " (copy)", Email: entry.Email}
+			m.showForm("Duplicate "+entry.Name, duplicate, m.store.Add, m.removeEntry)
+		}
+	case 'd':
+		if ok {
+			m.confirmDelete(entry)
+		}
+	case 'q':
+		m.app.Stop()
+	default:
+		return event
+	}
+
+	return nil
+}
+
+// refresh lists the profiles matching the filter by name, keeping the named
+// profile highlighted if it is still listed
+func (m *Manager) refresh(selected string) {
+	filter := strings.ToLower(strings.TrimSpace(m.filter.GetText()))
+
+	entries := m.store.List()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	m.entries = m.entries[:0]
+	for _, entry := range entries {
+		if strings.Contains(strings.ToLower(entry.Name), filter) || strings.Contains(strings.ToLower(entry.Email), filter) {
+			m.entries = append(m.entries, entry)
+		}
+	}
+
+	m.list.Clear()
+	current := 0
+	for i, entry := range m.entries {
+		m.list.AddItem(tview.Escape(entry.Name), tview.Escape(entry.Email), 0, nil)
+		if entry.Name == selected {
+			current = i
+		}
+	}
+	m.list.SetCurrentItem(current)
+
+	m.showDetails()
+}
+
+// showDetails describes the highlighted profile
+func (m *Manager) showDetails() {
+	entry, ok := m.selected()
+	if !ok {
+		m.details.SetText("No profile")
+		return
+	}
+
+	var b strings.Builder
+	field := func(name, value string) {
+		fmt.Fprintf(&b, "[::b]%-10s[::-] %s\n", name, tview.Escape(value))
+	}
+
+	field("Name", entry.Name)
+	field("Email", entry.Email)
+	if layered, ok := m.store.(*base.Layered); ok {
+		field("Origin", layered.Origin(entry.Name))
+	}
+
+	lastUsed := "never"
+	if t, ok := m.lastUsed[entry.Name]; ok {
+		lastUsed = t.Local().Format("2006-01-02 15:04")
+	}
+	field("Last used", lastUsed)
+
+	var active []string
+	for i, target := range m.targets {
+		if i < len(m.active) && m.active[i] == entry {
+			active = append(active, target.Name)
+		}
+	}
+	if len(active) > 0 {
+		field("Active in", strings.Join(active, ", "))
+	}
+
+	m.details.SetText(b.String())
+}
+
+// selected returns the highlighted profile, if any
+func (m *Manager) selected() (base.Entry, bool) {
+	i := m.list.GetCurrentItem()
+	if i < 0 || i >= len(m.entries) {
+		return base.Entry{}, false
+	}
+
+	return m.entries[i], true
+}
+
+func (m *Manager) selectedName() string {
+	entry, _ := m.selected()
+	return entry.Name
+}
+
+// save writes the changes made to the store, reporting the outcome
+func (m *Manager) save(success string) bool {
+	if err := m.store.Save(); err != nil {
+		m.setError("Can't save the database:", err)
+		return false
+	}
+
+	m.setStatus("[green]" + tview.Escape(success) + "[-]  " + help)
+	return true
+}
+
+// removeEntry reverts the addition of the profile
+func (m *Manager) removeEntry(entry base.Entry) error {
+	return m.store.Delete(entry.Name)
+}
+
+// restoreEntry reverts the edition of the profile prev into curr. Removing
+// curr uncovers prev when it comes from a read-only catalog.
+func (m *Manager) restoreEntry(curr, prev base.Entry) error {
+	if err := m.store.Delete(curr.Name); err != nil {
+		return err
+	}
+	if _, err := m.store.Get(prev.Name); err == nil {
+		return nil
+	}
+
+	return m.store.Add(prev)
+}
+
+// closePage removes the page shown over the list
+func (m *Manager) closePage(name string) {
+	m.pages.RemovePage(name)
+	m.app.SetFocus(m.list)
+}
+
+func (m *Manager) setStatus(text string) {
+	m.status.SetText(text)
+}
+
+func (m *Manager) setError(v ...interface{}) {
+	m.setStatus("[red]" + tview.Escape(strings.TrimSuffix(fmt.Sprintln(v...), "\n")) + "[-]")
+}
+
+// center returns the primitive centered on the screen with the given size
+func center(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 0, true).
+			AddItem(nil, 0, 1, false), width, 0, true).
+		AddItem(nil, 0, 1, false)
+}
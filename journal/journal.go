@@ -0,0 +1,337 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/tabarnhack/git-switch/config"
+	"github.com/tabarnhack/git-switch/io/print"
+)
+
+const (
+	indexName = "journal.json"
+	lockName  = "journal.lock"
+
+	// lockTimeout is how long to wait for another process to release the
+	// index, and staleLock the age from which a lock is considered left by
+	// a crashed process
+	lockTimeout = 5 * time.Second
+	staleLock   = time.Minute
+)
+
+var ErrNotFound = errors.New("backup not found")
+
+// notFound is the error about a missing backup, matching ErrNotFound
+type notFound string
+
+func (e notFound) Error() string {
+	return string(e)
+}
+
+func (e notFound) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+type Record struct {
+	ID   int
+	Path string
+	Time time.Time
+}
+
+type index struct {
+	NextID  int
+	Records []Record
+	// Used holds when each profile was last applied to a gitconfig file
+	Used map[string]time.Time `json:",omitempty"`
+}
+
+type Journal struct {
+	dir       string
+	retention int
+}
+
+// New returns the journal of the backup directory, which is only created
+// once something is written to it
+func New(conf config.BackupConfig) (*Journal, error) {
+	if conf.Path == "" {
+		return nil, errors.New("no backup path configured")
+	}
+
+	return &Journal{dir: conf.Path, retention: conf.Retention}, nil
+}
+
+// Snapshot stores the current content of the file before it gets overwritten.
+// The oldest snapshots of the file are removed once the retention count is
+// exceeded, the retention applying to each file separately.
+func (j *Journal) Snapshot(path string) (Record, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Record{}, err
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Record{}, err
+	}
+
+	var r Record
+	err = j.update(func(idx *index) error {
+		r = Record{ID: idx.NextID, Path: path, Time: time.Now()}
+		if err := os.WriteFile(j.snapshotFile(r.ID), content, 0600); err != nil {
+			return err
+		}
+
+		idx.NextID++
+		idx.Records = append(idx.Records, r)
+		idx.Records = j.prune(idx.Records, path)
+		return nil
+	})
+
+	return r, err
+}
+
+// prune removes the oldest snapshots of the file exceeding the retention. A
+// retention lower than 1 keeps every snapshot.
+func (j *Journal) prune(records []Record, path string) []Record {
+	if j.retention < 1 {
+		return records
+	}
+
+	count := 0
+	for _, r := range records {
+		if r.Path == path {
+			count++
+		}
+	}
+
+	kept := records[:0]
+	for _, r := range records {
+		if r.Path == path && count > j.retention {
+			os.Remove(j.snapshotFile(r.ID))
+			count--
+			continue
+		}
+		kept = append(kept, r)
+	}
+
+	return kept
+}
+
+// List returns the snapshots from the most recent to the oldest one.
+func (j *Journal) List() ([]Record, error) {
+	idx, err := j.load()
+	if err != nil {
+		return nil, err
+	}
+
+	records := idx.Records
+	sort.Slice(records, func(a, b int) bool {
+		return records[a].ID > records[b].ID
+	})
+
+	return records, nil
+}
+
+func (j *Journal) Get(id int) (Record, []byte, error) {
+	records, err := j.List()
+	if err != nil {
+		return Record{}, nil, err
+	}
+
+	for _, r := range records {
+		if r.ID == id {
+			content, err := os.ReadFile(j.snapshotFile(r.ID))
+			return r, content, err
+		}
+	}
+
+	return Record{}, nil, notFound(fmt.Sprintf("no backup with the id %d exists", id))
+}
+
+// Latest returns the most recent snapshot taken from the file.
+func (j *Journal) Latest(path string) (Record, []byte, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Record{}, nil, err
+	}
+
+	records, err := j.List()
+	if err != nil {
+		return Record{}, nil, err
+	}
+
+	for _, r := range records {
+		if r.Path == path {
+			return j.Get(r.ID)
+		}
+	}
+
+	return Record{}, nil, notFound(fmt.Sprintf("no backup exists for %s", path))
+}
+
+// Restore writes back the content of a snapshot. The content being replaced
+// is itself snapshotted so a restore can be undone.
+func (j *Journal) Restore(r Record, content []byte) error {
+	info, err := os.Stat(r.Path)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := j.Snapshot(r.Path)
+	if err != nil {
+		return err
+	}
+	print.Debug("Backed up", r.Path, "as backup", snapshot.ID)
+
+	if err := os.WriteFile(r.Path, content, info.Mode()); err != nil {
+		return err
+	}
+	print.Debug("Wrote backup", r.ID, "to", r.Path)
+
+	return nil
+}
+
+// Used records that the profile has just been applied to a gitconfig file.
+// The names missing from the known profiles, renamed or deleted since they
+// were used, are dropped.
+func (j *Journal) Used(name string, known []string) error {
+	return j.update(func(idx *index) error {
+		used := make(map[string]time.Time, len(known))
+		for _, k := range known {
+			if t, ok := idx.Used[k]; ok {
+				used[k] = t
+			}
+		}
+		used[name] = time.Now()
+		idx.Used = used
+		return nil
+	})
+}
+
+// LastUsed returns when each profile was last applied to a gitconfig file
+func (j *Journal) LastUsed() (map[string]time.Time, error) {
+	idx, err := j.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return idx.Used, nil
+}
+
+func (j *Journal) snapshotFile(id int) string {
+	return filepath.Join(j.dir, fmt.Sprintf("%d.gitconfig", id))
+}
+
+// update changes the index, locked from loading it to saving it. Reading the
+// index needs no lock, it is replaced at once when saved.
+func (j *Journal) update(fn func(idx *index) error) error {
+	if err := os.MkdirAll(j.dir, 0700); err != nil {
+		return err
+	}
+
+	unlock, err := j.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	idx, err := j.load()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&idx); err != nil {
+		return err
+	}
+
+	return j.save(idx)
+}
+
+// lock creates the lock file of the index, waiting for another process to
+// remove it. It returns the function removing it.
+func (j *Journal) lock() (func(), error) {
+	path := filepath.Join(j.dir, lockName)
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > staleLock {
+			print.Debug("Removing the stale lock", path)
+			os.Remove(path)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("the backup journal is locked by another process, remove %s if none is running", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func (j *Journal) load() (index, error) {
+	idx := index{NextID: 1}
+
+	data, err := os.ReadFile(filepath.Join(j.dir, indexName))
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return idx, err
+	}
+
+	err = json.Unmarshal(data, &idx)
+	return idx, err
+}
+
+// save writes the index to a temporary file renamed over the index, so
+// that it is never read half written
+func (j *Journal) save(idx index) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(j.dir, indexName+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), filepath.Join(j.dir, indexName))
+}
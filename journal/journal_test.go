@@ -0,0 +1,219 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package journal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tabarnhack/git-switch/config"
+)
+
+func newJournal(t *testing.T, retention int) *Journal {
+	t.Helper()
+
+	j, err := New(config.BackupConfig{Path: filepath.Join(t.TempDir(), "backups"), Retention: retention})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return j
+}
+
+func TestUsed(t *testing.T) {
+	tests := []struct {
+		name  string
+		used  []string
+		known []string
+		want  []string
+	}{
+		{"first use", []string{"work"}, []string{"work", "home"}, []string{"work"}},
+		{"several uses", []string{"work", "home", "work"}, []string{"work", "home"}, []string{"home", "work"}},
+		{"deleted profile", []string{"old", "work"}, []string{"work"}, []string{"work"}},
+		{"renamed profile", []string{"work", "home"}, []string{"job", "home"}, []string{"home"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			j := newJournal(t, 0)
+			for _, name := range test.used {
+				if err := j.Used(name, test.known); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			used, err := j.LastUsed()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for name := range used {
+				got = append(got, name)
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("LastUsed() = %v, want the profiles %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRetention(t *testing.T) {
+	tests := []struct {
+		name      string
+		retention int
+		snapshots []string
+		want      map[string][]int
+	}{
+		{"every backup kept", 0, []string{"a", "a", "a"}, map[string][]int{"a": {3, 2, 1}}},
+		{"oldest removed", 2, []string{"a", "a", "a"}, map[string][]int{"a": {3, 2}}},
+		{"per file", 2, []string{"a", "b", "a", "a", "a"}, map[string][]int{"a": {5, 4}, "b": {2}}},
+		{"one each", 1, []string{"a", "b", "c", "b"}, map[string][]int{"a": {1}, "b": {4}, "c": {3}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			j := newJournal(t, test.retention)
+
+			for i, name := range test.snapshots {
+				path := filepath.Join(dir, name)
+				if err := os.WriteFile(path, []byte(fmt.Sprint(i+1)), 0600); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := j.Snapshot(path); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			records, err := j.List()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string][]int)
+			for _, r := range records {
+				name := filepath.Base(r.Path)
+				got[name] = append(got[name], r.ID)
+
+				_, content, err := j.Get(r.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(content) != fmt.Sprint(r.ID) {
+					t.Errorf("backup %d holds %q, want %q", r.ID, content, fmt.Sprint(r.ID))
+				}
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("List() = %v, want the backups %v", got, test.want)
+			}
+
+			files, err := filepath.Glob(filepath.Join(j.dir, "*.gitconfig"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != len(records) {
+				t.Errorf("%d backup files left, want %d", len(files), len(records))
+			}
+		})
+	}
+}
+
+func TestConcurrentSnapshots(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gitconfig")
+	if err := os.WriteFile(path, []byte("[user]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	j := newJournal(t, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := j.Snapshot(path); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	records, err := j.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 10 {
+		t.Errorf("List() returned %d backups, want 10", len(records))
+	}
+}
+
+func TestLazyDirectory(t *testing.T) {
+	j := newJournal(t, 0)
+
+	if _, err := j.List(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.LastUsed(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(j.dir); !os.IsNotExist(err) {
+		t.Errorf("the backup directory exists before anything is written to it: %v", err)
+	}
+}
+
+func TestReadWhileLocked(t *testing.T) {
+	j := newJournal(t, 0)
+	if err := j.Used("work", []string{"work"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another process is writing to the journal
+	unlock, err := j.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	start := time.Now()
+	used, err := j.LastUsed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := used["work"]; !ok {
+		t.Errorf("LastUsed() = %v, want work", used)
+	}
+	if _, err := j.List(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= lockTimeout {
+		t.Errorf("reading the journal waited %v for the lock", elapsed)
+	}
+
+	files, err := filepath.Glob(filepath.Join(j.dir, indexName+".*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("temporary index files left: %v", files)
+	}
+}
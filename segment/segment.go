@@ -0,0 +1,227 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package segment
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tabarnhack/git-switch/base"
+	"github.com/tabarnhack/git-switch/config"
+	"github.com/tabarnhack/git-switch/gitconfig"
+)
+
+const (
+	cacheName = "segment.json"
+
+	// cacheVersion changes whenever the cached segments may be computed
+	// differently, discarding the cache
+	cacheVersion = 3
+
+	// maxCached bounds the number of repositories kept in the cache
+	maxCached = 256
+)
+
+// Segment is the git profile of a repository shown in the shell prompt
+type Segment struct {
+	// Profile is the name of the profile of the database matching the
+	// identity, empty if none matches
+	Profile string
+	Name    string
+	Email   string
+	// Scope is the scope of the gitconfig file setting the identity
+	Scope string
+}
+
+// keyEnv lists the environment variables changing the gitconfig files read.
+// The ones overriding the configuration keys are added by cacheKey.
+var keyEnv = []string{"GIT_CONFIG_GLOBAL", "GIT_CONFIG_SYSTEM", "GIT_CONFIG_NOSYSTEM", "XDG_CONFIG_HOME", "HOME"}
+
+// cacheFile holds the cached segments by key, see cacheKey
+type cacheFile struct {
+	Version      int
+	Repositories map[string]cached
+}
+
+// cached is a segment with the modification times of the files it has been
+// computed from, 0 for the missing ones
+type cached struct {
+	Segment Segment
+	Files   map[string]int64
+}
+
+// Get returns the segment of the repository of the git directory. It is
+// read from the cache while none of the gitconfig, config and database
+// files it depends on has changed. The database is only read, and never
+// unlocked by prompting.
+func Get(gitDir, configFile string) (Segment, error) {
+	cachePath := ""
+	if dir, err := config.CacheDir(); err == nil {
+		cachePath = filepath.Join(dir, cacheName)
+	}
+
+	key := cacheKey(gitDir, configFile)
+	cache := loadCache(cachePath)
+	if c, ok := cache[key]; ok && fresh(c.Files) {
+		return c.Segment, nil
+	}
+
+	effective, err := gitconfig.ResolveEffective(gitDir)
+	if err != nil {
+		return Segment{}, err
+	}
+
+	conf, files, err := config.Read(configFile)
+	if err != nil {
+		return Segment{}, err
+	}
+
+	s := Segment{Name: effective.Name, Email: effective.Email, Scope: effective.Scope}
+	store, openErr := base.Open(conf.Database, true)
+	if openErr == nil {
+		for _, entry := range store.List() {
+			if entry == effective.Entry {
+				s.Profile = entry.Name
+				break
+			}
+		}
+	}
+
+	files = append(files, effective.Files...)
+	if conf.Database.KeyFile != "" {
+		files = append(files, conf.Database.KeyFile)
+	}
+	if conf.Database.Path != "" {
+		files = append(files, conf.Database.Path)
+	} else {
+		for _, dir := range conf.Database.SearchPaths {
+			files = append(files, filepath.Join(dir, conf.Database.Filename))
+		}
+	}
+
+	// An unreadable database, locked or encrypted with another passphrase,
+	// is retried next time
+	if cachePath != "" && openErr == nil {
+		if len(cache) >= maxCached {
+			cache = make(map[string]cached)
+		}
+		cache[key] = cached{Segment: s, Files: modTimes(files)}
+		saveCache(cachePath, cache)
+	}
+
+	return s, nil
+}
+
+// cacheKey identifies a segment by the git directory and every input
+// choosing the files it is computed from
+func cacheKey(gitDir, configFile string) string {
+	parts := []string{gitDir, configFile}
+	names := append([]string{}, keyEnv...)
+	for _, key := range config.SortedKeys() {
+		names = append(names, config.EnvName(key))
+	}
+	for _, name := range names {
+		value, ok := os.LookupEnv(name)
+		if ok {
+			value = "=" + value
+		}
+		parts = append(parts, name+value)
+	}
+
+	// The passphrase itself is never written to the cache, the segments
+	// computed with a wrong one are not cached
+	if _, ok := os.LookupEnv(base.PassphraseEnv); ok {
+		parts = append(parts, base.PassphraseEnv)
+	}
+
+	return strings.Join(parts, "\x00")
+}
+
+func modTimes(files []string) map[string]int64 {
+	times := make(map[string]int64, len(files))
+	for _, file := range files {
+		times[file] = 0
+		if info, err := os.Stat(file); err == nil {
+			times[file] = info.ModTime().UnixNano()
+		}
+	}
+
+	return times
+}
+
+// fresh reports whether none of the files has changed
+func fresh(files map[string]int64) bool {
+	for file, t := range files {
+		var curr int64
+		if info, err := os.Stat(file); err == nil {
+			curr = info.ModTime().UnixNano()
+		}
+		if curr != t {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadCache reads the cached segments by key, an unreadable cache
+// being empty
+func loadCache(path string) map[string]cached {
+	cache := make(map[string]cached)
+	if path == "" {
+		return cache
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	var f cacheFile
+	if json.Unmarshal(data, &f) != nil || f.Version != cacheVersion || f.Repositories == nil {
+		return cache
+	}
+
+	return f.Repositories
+}
+
+// saveCache replaces the cache at once, so that concurrent prompts never
+// read it half written. Failures are ignored as it is only a cache.
+func saveCache(path string, cache map[string]cached) {
+	data, err := json.Marshal(cacheFile{Version: cacheVersion, Repositories: cache})
+	if err != nil {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), cacheName+".*")
+	if err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err != nil || closeErr != nil {
+		return
+	}
+
+	os.Rename(tmp.Name(), path)
+}
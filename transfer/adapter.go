@@ -0,0 +1,230 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package transfer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/tabarnhack/git-switch/base"
+	"gopkg.in/ini.v1"
+	"gopkg.in/yaml.v3"
+)
+
+// Migrated is a profile read from another identity switcher
+type Migrated struct {
+	base.Entry
+
+	// Source names the profile in the other tool
+	Source string
+	// Unmapped lists the fields of the profile with no equivalent
+	Unmapped []string
+}
+
+// Adapter reads the profiles stored on disk by another identity switcher
+type Adapter interface {
+	Name() string
+	// DefaultPath is where the tool stores its profiles, relative to the home directory
+	DefaultPath() string
+	Read(path string) ([]Migrated, error)
+}
+
+var Adapters = []Adapter{gitSwitcher{}, gitego{}, jsonProfiles{}}
+
+func AdapterByName(name string) (Adapter, error) {
+	var names []string
+	for _, adapter := range Adapters {
+		if adapter.Name() == name {
+			return adapter, nil
+		}
+		names = append(names, adapter.Name())
+	}
+
+	return nil, fmt.Errorf("unknown identity switcher %s, expected one of %v", name, names)
+}
+
+// Migrate reads the profiles of the adapter's tool, from its default location
+// if no path is given
+func Migrate(adapter Adapter, path string) ([]Migrated, error) {
+	if path == "" {
+		if adapter.DefaultPath() == "" {
+			return nil, fmt.Errorf("%s has no default location, a path is required", adapter.Name())
+		}
+
+		home, err := homedir.Dir()
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(home, adapter.DefaultPath())
+	}
+
+	return adapter.Read(path)
+}
+
+// gitSwitcher reads git-switcher profiles, each one being a whole gitconfig
+// file stored in the same directory
+type gitSwitcher struct{}
+
+func (gitSwitcher) Name() string {
+	return "git-switcher"
+}
+
+func (gitSwitcher) DefaultPath() string {
+	return filepath.Join(".config", "gitconfigs")
+}
+
+func (gitSwitcher) Read(path string) ([]Migrated, error) {
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []Migrated
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		cfg, err := ini.Load(filepath.Join(path, file.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse %s: %s", file.Name(), err)
+		}
+
+		p := Migrated{Source: file.Name()}
+		for _, section := range cfg.Sections() {
+			for _, key := range section.Keys() {
+				switch {
+				case section.Name() == "user" && key.Name() == "name":
+					p.Name = key.String()
+				case section.Name() == "user" && key.Name() == "email":
+					p.Email = key.String()
+				default:
+					p.Unmapped = append(p.Unmapped, section.Name()+"."+key.Name())
+				}
+			}
+		}
+
+		profiles = append(profiles, p)
+	}
+
+	return profiles, nil
+}
+
+// gitego reads the profiles section of the gitego YAML configuration, where
+// profiles are stored by alias
+type gitego struct{}
+
+func (gitego) Name() string {
+	return "gitego"
+}
+
+func (gitego) DefaultPath() string {
+	return filepath.Join(".gitego", "config.yaml")
+}
+
+func (gitego) Read(path string) ([]Migrated, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var content struct {
+		Profiles map[string]map[string]interface{} `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+
+	profiles := make([]Migrated, 0, len(content.Profiles))
+	for alias, fields := range content.Profiles {
+		profiles = append(profiles, migrateFields(alias, fields))
+	}
+
+	return sortMigrated(profiles), nil
+}
+
+// jsonProfiles reads profiles stored as JSON by git-user style tools, either
+// as an array of profiles or as an object of profiles by alias
+type jsonProfiles struct{}
+
+func (jsonProfiles) Name() string {
+	return "json"
+}
+
+func (jsonProfiles) DefaultPath() string {
+	return ""
+}
+
+func (jsonProfiles) Read(path string) ([]Migrated, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var profiles []Migrated
+
+	var list []map[string]interface{}
+	if err := json.Unmarshal(data, &list); err == nil {
+		for i, fields := range list {
+			profiles = append(profiles, migrateFields(fmt.Sprintf("#%d", i+1), fields))
+		}
+		return profiles, nil
+	}
+
+	var byAlias map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &byAlias); err != nil {
+		return nil, fmt.Errorf("expected an array or an object of profiles: %s", err)
+	}
+
+	for alias, fields := range byAlias {
+		profiles = append(profiles, migrateFields(alias, fields))
+	}
+
+	return sortMigrated(profiles), nil
+}
+
+// migrateFields maps the name and email fields of a profile, whatever their
+// case, every other field being reported as unmapped
+func migrateFields(source string, fields map[string]interface{}) Migrated {
+	p := Migrated{Source: source}
+	for key, value := range fields {
+		s, isString := value.(string)
+		switch {
+		case isString && strings.EqualFold(key, "name"):
+			p.Name = s
+		case isString && strings.EqualFold(key, "email"):
+			p.Email = s
+		default:
+			p.Unmapped = append(p.Unmapped, key)
+		}
+	}
+
+	sort.Strings(p.Unmapped)
+	return p
+}
+
+func sortMigrated(profiles []Migrated) []Migrated {
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Source < profiles[j].Source
+	})
+
+	return profiles
+}
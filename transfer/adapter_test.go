@@ -0,0 +1,115 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package transfer
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+// writeFixture writes the files by path relative to a temporary directory,
+// which is returned
+func writeFixture(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestAdapters(t *testing.T) {
+	tests := []struct {
+		name    string
+		adapter string
+		files   map[string]string
+		path    string
+		want    []Migrated
+	}{
+		{"git-switcher", "git-switcher", map[string]string{
+			"gitconfigs/personal": "[user]\n\tname = Jane\n\temail = jane@home.org\n",
+			"gitconfigs/work":     "[user]\n\tname = Jane Doe\n\temail = jane@corp.com\n\tsigningkey = ABCD\n[commit]\n\tgpgsign = true\n",
+			"gitconfigs/sub/skip": "[user]\n\tname = Nested\n",
+		}, "gitconfigs", []Migrated{
+			{Entry: base.Entry{Name: "Jane", Email: "jane@home.org"}, Source: "personal"},
+			{Entry: base.Entry{Name: "Jane Doe", Email: "jane@corp.com"}, Source: "work", Unmapped: []string{"user.signingkey", "commit.gpgsign"}},
+		}},
+		{"gitego", "gitego", map[string]string{
+			"config.yaml": `active_profile: work
+profiles:
+  work:
+    name: Jane Doe
+    email: jane@corp.com
+    ssh_key: ~/.ssh/id_work
+    pat: secret
+  personal:
+    name: Jane
+    email: jane@home.org
+`,
+		}, "config.yaml", []Migrated{
+			{Entry: base.Entry{Name: "Jane", Email: "jane@home.org"}, Source: "personal"},
+			{Entry: base.Entry{Name: "Jane Doe", Email: "jane@corp.com"}, Source: "work", Unmapped: []string{"pat", "ssh_key"}},
+		}},
+		{"json array", "json", map[string]string{
+			"profiles.json": `[{"Name": "Jane", "Email": "jane@home.org"}, {"name": "Jane Doe", "email": "jane@corp.com", "signingKey": "ABCD"}]`,
+		}, "profiles.json", []Migrated{
+			{Entry: base.Entry{Name: "Jane", Email: "jane@home.org"}, Source: "#1"},
+			{Entry: base.Entry{Name: "Jane Doe", Email: "jane@corp.com"}, Source: "#2", Unmapped: []string{"signingKey"}},
+		}},
+		{"json object", "json", map[string]string{
+			"profiles.json": `{"work": {"name": "Jane Doe", "email": "jane@corp.com", "host": "github.com"}, "home": {"name": "Jane", "email": 42}}`,
+		}, "profiles.json", []Migrated{
+			{Entry: base.Entry{Name: "Jane"}, Source: "home", Unmapped: []string{"email"}},
+			{Entry: base.Entry{Name: "Jane Doe", Email: "jane@corp.com"}, Source: "work", Unmapped: []string{"host"}},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			adapter, err := AdapterByName(test.adapter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dir := writeFixture(t, test.files)
+			got, err := Migrate(adapter, filepath.Join(dir, test.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Migrate() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAdapterByName(t *testing.T) {
+	if _, err := AdapterByName("gitswitch"); err == nil {
+		t.Error("AdapterByName() error = nil, want an error")
+	}
+}
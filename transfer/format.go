@@ -0,0 +1,159 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package transfer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/tabarnhack/git-switch/base"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	JSON = "json"
+	YAML = "yaml"
+	CSV  = "csv"
+)
+
+var Formats = []string{JSON, YAML, CSV}
+
+type profile struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+}
+
+// FormatFromFilename guesses the format of a file from its extension
+func FormatFromFilename(filename string) (string, bool) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return JSON, true
+	case ".yml", ".yaml":
+		return YAML, true
+	case ".csv":
+		return CSV, true
+	}
+
+	return "", false
+}
+
+func Encode(w io.Writer, format string, entries []base.Entry) error {
+	profiles := make([]profile, 0, len(entries))
+	for _, entry := range entries {
+		profiles = append(profiles, profile{Name: entry.Name, Email: entry.Email})
+	}
+
+	switch format {
+	case JSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(profiles)
+	case YAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(profiles); err != nil {
+			return err
+		}
+		return enc.Close()
+	case CSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"name", "email"})
+		for _, p := range profiles {
+			cw.Write([]string{p.Name, p.Email})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	return fmt.Errorf("unknown format %s, expected one of %v", format, Formats)
+}
+
+func Decode(r io.Reader, format string) ([]base.Entry, error) {
+	var profiles []profile
+
+	switch format {
+	case JSON:
+		if err := json.NewDecoder(r).Decode(&profiles); err != nil {
+			return nil, err
+		}
+	case YAML:
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &profiles); err != nil {
+			return nil, err
+		}
+	case CSV:
+		var err error
+		profiles, err = decodeCSV(r)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown format %s, expected one of %v", format, Formats)
+	}
+
+	entries := make([]base.Entry, 0, len(profiles))
+	for _, p := range profiles {
+		entries = append(entries, base.Entry{Name: strings.TrimSpace(p.Name), Email: strings.TrimSpace(p.Email)})
+	}
+
+	return entries, nil
+}
+
+// decodeCSV reads a CSV file whose header names the name and email columns
+func decodeCSV(r io.Reader) ([]profile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	name, email := -1, -1
+	for i, column := range records[0] {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "name":
+			name = i
+		case "email":
+			email = i
+		}
+	}
+
+	if name == -1 || email == -1 {
+		return nil, fmt.Errorf("the CSV header must contain the name and email columns")
+	}
+
+	profiles := make([]profile, 0, len(records)-1)
+	for _, record := range records[1:] {
+		profiles = append(profiles, profile{Name: record[name], Email: record[email]})
+	}
+
+	return profiles, nil
+}
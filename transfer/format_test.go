@@ -0,0 +1,104 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package transfer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+func TestDecodeCSV(t *testing.T) {
+	jane := base.Entry{Name: "jane", Email: "jane@corp.com"}
+	john := base.Entry{Name: "john", Email: "john@corp.com"}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []base.Entry
+		fails bool
+	}{
+		{"header", "name,email\njane,jane@corp.com\njohn,john@corp.com\n", []base.Entry{jane, john}, false},
+		{"columns in any order", "email,name\njane@corp.com,jane\n", []base.Entry{jane}, false},
+		{"header case and spaces", " Email , NAME \njane@corp.com,jane\n", []base.Entry{jane}, false},
+		{"other columns", "team,name,email\ndev,jane,jane@corp.com\n", []base.Entry{jane}, false},
+		{"values trimmed", "name,email\n jane , jane@corp.com \n", []base.Entry{jane}, false},
+		{"header only", "name,email\n", []base.Entry{}, false},
+		{"empty", "", []base.Entry{}, false},
+		{"no email column", "name,mail\njane,jane@corp.com\n", nil, true},
+		{"no header", "jane,jane@corp.com\n", nil, true},
+		{"missing field", "name,email\njane\n", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Decode(strings.NewReader(test.input), CSV)
+			if (err != nil) != test.fails {
+				t.Fatalf("Decode() error = %v, want an error %v", err, test.fails)
+			}
+			if !test.fails && !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Decode() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	entries := []base.Entry{{Name: "jane", Email: "jane@corp.com"}, {Name: "Doe, John", Email: "john@corp.com"}}
+
+	for _, format := range Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, format, entries); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Decode(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, entries) {
+				t.Errorf("Decode(Encode()) = %v, want %v", got, entries)
+			}
+		})
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+		ok       bool
+	}{
+		{"profiles.json", JSON, true},
+		{"profiles.YML", YAML, true},
+		{"profiles.yaml", YAML, true},
+		{"export.csv", CSV, true},
+		{"profiles", "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			got, ok := FormatFromFilename(test.filename)
+			if got != test.want || ok != test.ok {
+				t.Errorf("FormatFromFilename() = %v, %v, want %v, %v", got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+// halfLife is the age at which a commit counts half as much in the ranking
+const halfLife = 180 * 24 * time.Hour
+
+// Candidate is an identity found in the history of a repository
+type Candidate struct {
+	base.Entry
+
+	Commits int
+	Last    time.Time
+	Score   float64
+}
+
+// FromLog returns the author and committer identities of the repository
+// history. They are ranked by their number of commits, each commit weighing
+// less as it gets older, so frequent and recent identities come first.
+func FromLog(repo string) ([]Candidate, error) {
+	out, err := exec.Command("git", "-C", repo, "log", "--all",
+		"--format=%an%x00%ae%x00%at%x00%cn%x00%ce%x00%ct").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git log failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, err
+	}
+
+	return rank(out, time.Now())
+}
+
+// rank scores the identities of the git log output, with the author and
+// committer of each commit on a line, as of now
+func rank(out []byte, now time.Time) ([]Candidate, error) {
+	candidates := make(map[base.Entry]*Candidate)
+	add := func(name, email, timestamp string) {
+		entry := base.Entry{Name: strings.TrimSpace(name), Email: strings.TrimSpace(email)}
+		if entry.IsIncomplete() {
+			return
+		}
+
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return
+		}
+		date := time.Unix(seconds, 0)
+
+		c, ok := candidates[entry]
+		if !ok {
+			c = &Candidate{Entry: entry}
+			candidates[entry] = c
+		}
+
+		c.Commits++
+		c.Score += math.Pow(0.5, float64(now.Sub(date))/float64(halfLife))
+		if date.After(c.Last) {
+			c.Last = date
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\x00")
+		if len(fields) != 6 {
+			continue
+		}
+
+		add(fields[0], fields[1], fields[2])
+		// Only count the committer when it differs from the author
+		if fields[0] != fields[3] || fields[1] != fields[4] {
+			add(fields[3], fields[4], fields[5])
+		}
+	}
+
+	ranked := make([]Candidate, 0, len(candidates))
+	for _, c := range candidates {
+		ranked = append(ranked, *c)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		if ranked[i].Name != ranked[j].Name {
+			return ranked[i].Name < ranked[j].Name
+		}
+		return ranked[i].Email < ranked[j].Email
+	})
+
+	return ranked, scanner.Err()
+}
@@ -0,0 +1,119 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package transfer
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRank(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	// commit is a git log line authored and committed by the same identity
+	commit := func(name, email string, age time.Duration) string {
+		at := now.Add(-age).Unix()
+		return fmt.Sprintf("%s\x00%s\x00%d\x00%s\x00%s\x00%d", name, email, at, name, email, at)
+	}
+	committed := func(author, committer string, age time.Duration) string {
+		at := now.Add(-age).Unix()
+		return fmt.Sprintf("%s\x00%s@x.io\x00%d\x00%s\x00%s@x.io\x00%d", author, author, at, committer, committer, at)
+	}
+
+	type ranked struct {
+		Email   string
+		Commits int
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  []ranked
+	}{
+		{"frequent first", []string{
+			commit("Jane", "jane@corp.com", day),
+			commit("John", "john@corp.com", day),
+			commit("Jane", "jane@corp.com", 2*day),
+		}, []ranked{{"jane@corp.com", 2}, {"john@corp.com", 1}}},
+		{"recent first", []string{
+			commit("Jane", "jane@old.com", 700*day),
+			commit("Jane", "jane@old.com", 710*day),
+			commit("Jane", "jane@old.com", 720*day),
+			commit("Jane", "jane@new.com", day),
+		}, []ranked{{"jane@new.com", 1}, {"jane@old.com", 3}}},
+		{"committer counted apart", []string{
+			committed("jane", "bot", day),
+			committed("jane", "bot", day),
+			committed("john", "john", day),
+		}, []ranked{{"bot@x.io", 2}, {"jane@x.io", 2}, {"john@x.io", 1}}},
+		{"ties by name then email", []string{
+			commit("Jane", "jane@b.com", day),
+			commit("Jane", "jane@a.com", day),
+			commit("Al", "al@c.com", day),
+		}, []ranked{{"al@c.com", 1}, {"jane@a.com", 1}, {"jane@b.com", 1}}},
+		{"invalid lines skipped", []string{
+			commit("", "ghost@corp.com", day),
+			commit("Jane", "", day),
+			"Jane\x00jane@corp.com\x00yesterday\x00Jane\x00jane@corp.com\x00yesterday",
+			"Jane\x00jane@corp.com",
+			commit("John", "john@corp.com", day),
+		}, []ranked{{"john@corp.com", 1}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			candidates, err := rank([]byte(strings.Join(test.lines, "\n")), now)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []ranked
+			for _, c := range candidates {
+				got = append(got, ranked{c.Email, c.Commits})
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("rank() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRankScore(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	out := fmt.Sprintf("Jane\x00jane@corp.com\x00%d\x00Jane\x00jane@corp.com\x00%d\nJane\x00jane@corp.com\x00%d\x00Jane\x00jane@corp.com\x00%d",
+		now.Unix(), now.Unix(), now.Add(-halfLife).Unix(), now.Add(-halfLife).Unix())
+
+	candidates, err := rank([]byte(out), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("rank() = %v, want one candidate", candidates)
+	}
+
+	// A commit as old as the half-life counts half
+	c := candidates[0]
+	if c.Score != 1.5 {
+		t.Errorf("Score = %v, want 1.5", c.Score)
+	}
+	if !c.Last.Equal(now) {
+		t.Errorf("Last = %v, want %v", c.Last, now)
+	}
+}
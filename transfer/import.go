@@ -0,0 +1,148 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package transfer
+
+import (
+	"fmt"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+// Strategy is the way an imported profile is handled when a profile with
+// the same name but a different email already exists
+type Strategy string
+
+const (
+	Skip      Strategy = "skip"
+	Overwrite Strategy = "overwrite"
+	Rename    Strategy = "rename"
+	Fail      Strategy = "fail"
+)
+
+var Strategies = []Strategy{Skip, Overwrite, Rename, Fail}
+
+func ParseStrategy(s string) (Strategy, error) {
+	for _, strategy := range Strategies {
+		if Strategy(s) == strategy {
+			return strategy, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown conflict strategy %s, expected one of %v", s, Strategies)
+}
+
+// Invalid is an imported profile rejected by the validation
+type Invalid struct {
+	Entry base.Entry
+	Err   error
+}
+
+// Report sums up what has been done with each imported profile
+type Report struct {
+	Added       []base.Entry
+	Overwritten []base.Entry
+	Renamed     []base.Entry
+	Skipped     []base.Entry
+	Unchanged   []base.Entry
+	Invalid     []Invalid
+}
+
+// Import adds the entries to the store, handling name conflicts with the
+// strategy. Nothing is saved, the caller decides whether to save the store.
+func Import(store base.ProfileStore, entries []base.Entry, strategy Strategy) (Report, error) {
+	var report Report
+
+	for _, entry := range entries {
+		if err := entry.Validate(); err != nil {
+			report.Invalid = append(report.Invalid, Invalid{Entry: entry, Err: err})
+			continue
+		}
+
+		existing, err := store.Get(entry.Name)
+		if err != nil {
+			if err := store.Add(entry); err != nil {
+				return report, err
+			}
+			report.Added = append(report.Added, entry)
+			continue
+		}
+
+		if existing == entry {
+			report.Unchanged = append(report.Unchanged, entry)
+			continue
+		}
+
+		switch strategy {
+		case Skip:
+			report.Skipped = append(report.Skipped, entry)
+		case Overwrite:
+			if err := store.Update(existing, entry); err != nil {
+				return report, err
+			}
+			report.Overwritten = append(report.Overwritten, entry)
+		case Rename:
+			entry.Name = freeName(store, entry.Name)
+			if err := store.Add(entry); err != nil {
+				return report, err
+			}
+			report.Renamed = append(report.Renamed, entry)
+		default:
+			return report, fmt.Errorf("a different profile with the name %s already exists", entry.Name)
+		}
+	}
+
+	return report, nil
+}
+
+// freeName returns the first name not used in the store by suffixing a number
+func freeName(store base.ProfileStore, name string) string {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)", name, i)
+		if _, err := store.Get(candidate); err != nil {
+			return candidate
+		}
+	}
+}
+
+// Disambiguate suffixes the names shared by several entries, from the second
+// one on, with a number so that none of them conflicts with another. The
+// suffixed names are not used in the store either.
+func Disambiguate(store base.ProfileStore, entries []base.Entry) []base.Entry {
+	used := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		used[entry.Name] = true
+	}
+
+	seen := make(map[string]bool, len(entries))
+	result := make([]base.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if seen[entry.Name] {
+			name := entry.Name
+			for i := 2; ; i++ {
+				entry.Name = fmt.Sprintf("%s (%d)", name, i)
+				if _, err := store.Get(entry.Name); err != nil && !used[entry.Name] {
+					break
+				}
+			}
+			used[entry.Name] = true
+		}
+
+		seen[entry.Name] = true
+		result = append(result, entry)
+	}
+
+	return result
+}
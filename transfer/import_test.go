@@ -0,0 +1,148 @@
+/*
+Copyright © 2021 Tabarnhack <tabarnhack@outlook.fr>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package transfer
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/tabarnhack/git-switch/base"
+)
+
+func sorted(store base.ProfileStore) []base.Entry {
+	entries := store.List()
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name < entries[j].Name
+	})
+
+	return entries
+}
+
+func TestImport(t *testing.T) {
+	work := base.Entry{Name: "work", Email: "jane@corp.com"}
+	home := base.Entry{Name: "home", Email: "jane@home.org"}
+	taken := base.Entry{Name: "work (2)", Email: "jane@old.com"}
+
+	newWork := base.Entry{Name: "work", Email: "jane@newcorp.com"}
+	oss := base.Entry{Name: "oss", Email: "jane@oss.org"}
+	noName := base.Entry{Name: "", Email: "jane@corp.com"}
+	badEmail := base.Entry{Name: "bad", Email: "not an email"}
+	entries := []base.Entry{newWork, home, oss, noName, badEmail}
+
+	tests := []struct {
+		strategy Strategy
+		want     Report
+		stored   []base.Entry
+		fails    bool
+	}{
+		{Skip, Report{Added: []base.Entry{oss}, Skipped: []base.Entry{newWork}, Unchanged: []base.Entry{home}},
+			[]base.Entry{home, oss, work, taken}, false},
+		{Overwrite, Report{Added: []base.Entry{oss}, Overwritten: []base.Entry{newWork}, Unchanged: []base.Entry{home}},
+			[]base.Entry{home, oss, newWork, taken}, false},
+		// The first free suffix is used
+		{Rename, Report{Added: []base.Entry{oss}, Renamed: []base.Entry{{Name: "work (3)", Email: newWork.Email}}, Unchanged: []base.Entry{home}},
+			[]base.Entry{home, oss, work, taken, {Name: "work (3)", Email: newWork.Email}}, false},
+		{Fail, Report{}, []base.Entry{home, work, taken}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.strategy), func(t *testing.T) {
+			store := base.NewMemory(work, home, taken)
+
+			report, err := Import(store, entries, test.strategy)
+			if (err != nil) != test.fails {
+				t.Fatalf("Import() error = %v, want an error %v", err, test.fails)
+			}
+			if test.fails {
+				if got := sorted(store); !reflect.DeepEqual(got, test.stored) {
+					t.Errorf("store = %v, want %v", got, test.stored)
+				}
+				return
+			}
+
+			var invalid []base.Entry
+			for _, i := range report.Invalid {
+				if i.Err == nil {
+					t.Errorf("Invalid %v has no error", i.Entry)
+				}
+				invalid = append(invalid, i.Entry)
+			}
+			if want := []base.Entry{noName, badEmail}; !reflect.DeepEqual(invalid, want) {
+				t.Errorf("Invalid = %v, want %v", invalid, want)
+			}
+
+			report.Invalid = nil
+			if !reflect.DeepEqual(report, test.want) {
+				t.Errorf("Import() = %+v, want %+v", report, test.want)
+			}
+			if got := sorted(store); !reflect.DeepEqual(got, test.stored) {
+				t.Errorf("store = %v, want %v", got, test.stored)
+			}
+		})
+	}
+}
+
+func TestParseStrategy(t *testing.T) {
+	for _, strategy := range Strategies {
+		if got, err := ParseStrategy(string(strategy)); err != nil || got != strategy {
+			t.Errorf("ParseStrategy(%q) = %v, %v, want %v", strategy, got, err, strategy)
+		}
+	}
+
+	if _, err := ParseStrategy("merge"); err == nil {
+		t.Error("ParseStrategy(\"merge\") error = nil, want an error")
+	}
+}
+
+func TestDisambiguate(t *testing.T) {
+	tests := []struct {
+		name    string
+		stored  []base.Entry
+		entries []base.Entry
+		want    []string
+	}{
+		{"distinct names", nil,
+			[]base.Entry{{Name: "jane", Email: "a@x.io"}, {Name: "john", Email: "b@x.io"}},
+			[]string{"jane", "john"}},
+		{"shared name", nil,
+			[]base.Entry{{Name: "jane", Email: "a@x.io"}, {Name: "jane", Email: "b@x.io"}, {Name: "jane", Email: "c@x.io"}},
+			[]string{"jane", "jane (2)", "jane (3)"}},
+		{"suffix used in the store", []base.Entry{{Name: "jane (2)", Email: "z@x.io"}},
+			[]base.Entry{{Name: "jane", Email: "a@x.io"}, {Name: "jane", Email: "b@x.io"}},
+			[]string{"jane", "jane (3)"}},
+		{"suffix used by another entry", nil,
+			[]base.Entry{{Name: "jane", Email: "a@x.io"}, {Name: "jane", Email: "b@x.io"}, {Name: "jane (2)", Email: "c@x.io"}},
+			[]string{"jane", "jane (3)", "jane (2)"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Disambiguate(base.NewMemory(test.stored...), test.entries)
+
+			var names []string
+			for i, entry := range got {
+				names = append(names, entry.Name)
+				if entry.Email != test.entries[i].Email {
+					t.Errorf("entry %d has the email %s, want %s", i, entry.Email, test.entries[i].Email)
+				}
+			}
+			if !reflect.DeepEqual(names, test.want) {
+				t.Errorf("Disambiguate() = %v, want the names %v", names, test.want)
+			}
+		})
+	}
+}